@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bsm/feedx"
+)
+
+// cat prints up to limit records as JSON, one per line. A negative limit
+// prints all records.
+func cat(ctx context.Context, w io.Writer, url string, ro *readFlags, limit int) error {
+	obj, err := openObject(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	opt, err := ro.readerOptions(obj.Name())
+	if err != nil {
+		return err
+	}
+	if opt.Format == feedx.ProtobufFormat && opt.MessageType == nil {
+		return errors.New("cannot print protobuf records without a message type, please specify -type")
+	}
+
+	r, err := feedx.NewReader(ctx, obj, opt)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	enc := json.NewEncoder(w)
+	for n := 0; limit < 0 || n < limit; n++ {
+		rec, err := decodeRecord(r, opt)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// count prints the number of records.
+func count(ctx context.Context, w io.Writer, url string, ro *readFlags) error {
+	obj, err := openObject(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	opt, err := ro.readerOptions(obj.Name())
+	if err != nil {
+		return err
+	}
+
+	r, err := feedx.NewReader(ctx, obj, opt)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for {
+		if _, err := decodeRecord(r, opt); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintln(w, r.NumRead())
+	return err
+}
+
+// info prints object metadata.
+func info(ctx context.Context, w io.Writer, url string) error {
+	obj, err := openObject(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	meta, err := obj.Head(ctx)
+	if err != nil {
+		return err
+	}
+
+	r, err := feedx.NewReader(ctx, obj, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	version, err := r.Version()
+	if err != nil {
+		return err
+	}
+
+	sortedBy, err := r.SortedBy()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "name:\t%s\n", obj.Name())
+	fmt.Fprintf(tw, "size:\t%d\n", meta.Size)
+	fmt.Fprintf(tw, "modified:\t%s\n", meta.ModTime.UTC().Format(time.RFC3339))
+	fmt.Fprintf(tw, "version:\t%d\n", version)
+	fmt.Fprintf(tw, "format:\t%s\n", formatName(feedx.DetectFormat(obj.Name())))
+	fmt.Fprintf(tw, "compression:\t%s\n", compressionName(feedx.DetectCompression(obj.Name())))
+	if sortedBy != "" {
+		fmt.Fprintf(tw, "sorted by:\t%s\n", sortedBy)
+	}
+	return tw.Flush()
+}
+
+// printManifest pretty-prints the manifest of an incremental or
+// partitioned feed.
+func printManifest(ctx context.Context, w io.Writer, url string) error {
+	obj, err := openObject(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	if _, err := obj.Head(ctx); err != nil {
+		return err
+	}
+
+	r, err := feedx.NewReader(ctx, obj, &feedx.ReaderOptions{Format: feedx.JSONFormat})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var data json.RawMessage
+	if err := r.Decode(&data); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// convert converts a feed from src to dst and prints the number of
+// converted records.
+func convert(ctx context.Context, w io.Writer, dstURL, srcURL string, ro *readFlags) error {
+	src, err := openObject(ctx, srcURL)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := openObject(ctx, dstURL)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	ropt, err := ro.readerOptions(src.Name())
+	if err != nil {
+		return err
+	}
+
+	status, err := feedx.Convert(ctx, dst, src, &feedx.ConvertOptions{Reader: ropt})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "converted %d records (version %d) to %s\n", status.NumItems, status.RemoteVersion, dst.Name())
+	return err
+}
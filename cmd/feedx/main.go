@@ -0,0 +1,117 @@
+// Command feedx inspects feeds on the local file system.
+//
+// Usage:
+//
+//	feedx cat [READ FLAGS] URL            print all records as JSON
+//	feedx head [-n N] [READ FLAGS] URL    print the first N records as JSON
+//	feedx count [READ FLAGS] URL          print the number of records
+//	feedx info URL                        print object metadata
+//	feedx manifest URL                    pretty-print a feed manifest
+//	feedx convert [READ FLAGS] SRC DST    convert a feed to another format or compression
+//
+// Read flags:
+//
+//	-format F               data format (default: detected from URL)
+//	-compression C          compression (default: detected from URL)
+//	-type T                 full name of the protobuf message type
+//	-descriptor-set FILE    FileDescriptorSet which contains the message type
+//
+// URLs without a scheme are treated as local file paths. Only file:// URLs
+// are supported, remote storage such as s3:// or gs:// requires a build
+// which imports the respective bfs backend.
+//
+// Protobuf records are decoded using a message type, which is looked up by
+// its full name in a FileDescriptorSet file, as generated by:
+//
+//	protoc --include_imports --descriptor_set_out=FILE
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+const usage = `Usage: feedx <command> [flags] URL...
+
+Commands:
+  cat       print all records as JSON
+  head      print the first records as JSON
+  count     print the number of records
+  info      print object metadata
+  manifest  pretty-print a feed manifest
+  convert   convert a feed to another format or compression
+
+Run 'feedx <command> -h' for command flags.
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout); errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "feedx:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return flag.ErrHelp
+	}
+
+	name, args := args[0], args[1:]
+	fs := flag.NewFlagSet("feedx "+name, flag.ContinueOnError)
+
+	switch name {
+	case "cat":
+		var ro readFlags
+		ro.register(fs)
+		return runWithURL(fs, args, func(url string) error { return cat(ctx, w, url, &ro, -1) })
+	case "head":
+		var ro readFlags
+		ro.register(fs)
+		n := fs.Int("n", 10, "number of records to print")
+		return runWithURL(fs, args, func(url string) error { return cat(ctx, w, url, &ro, *n) })
+	case "count":
+		var ro readFlags
+		ro.register(fs)
+		return runWithURL(fs, args, func(url string) error { return count(ctx, w, url, &ro) })
+	case "info":
+		return runWithURL(fs, args, func(url string) error { return info(ctx, w, url) })
+	case "manifest":
+		return runWithURL(fs, args, func(url string) error { return printManifest(ctx, w, url) })
+	case "convert":
+		var ro readFlags
+		ro.register(fs)
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 2 {
+			return fmt.Errorf("%s expects SRC and DST URL arguments", fs.Name())
+		}
+		return convert(ctx, w, fs.Arg(1), fs.Arg(0), &ro)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stderr, usage)
+		return flag.ErrHelp
+	default:
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+func runWithURL(fs *flag.FlagSet, args []string, fn func(string) error) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%s expects exactly one URL argument", fs.Name())
+	}
+	return fn(fs.Arg(0))
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+	"github.com/bsm/feedx/internal/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestRun(t *testing.T) {
+	ctx := t.Context()
+	writeFeed(t, "mem://test/todos.json.gz", 3, 101)
+	writeFeed(t, "mem://test/todos.cbor", 2, 102)
+	writeFeed(t, "mem://test/todos.pb.zst", 4, 103)
+	writeFeed(t, "mem://test/manifest.json", 0, 0, &testManifest{
+		Version:    101,
+		Generation: 2,
+		Files:      []string{"data-0-100.json.gz", "data-1-101.json.gz"},
+	})
+
+	t.Run("cat", func(t *testing.T) {
+		if exp, got := strings.Repeat(`{"name":"Joe","enum":3,"height":180}`+"\n", 3), runOK(t, "cat", "mem://test/todos.json.gz"); exp != got {
+			t.Errorf("expected:\n%s\ngot:\n%s", exp, got)
+		}
+		if exp, got := strings.Repeat(`{"enum":3,"height":180,"name":"Joe"}`+"\n", 2), runOK(t, "cat", "mem://test/todos.cbor"); exp != got {
+			t.Errorf("expected:\n%s\ngot:\n%s", exp, got)
+		}
+		if err := run(ctx, []string{"cat", "mem://test/todos.pb.zst"}, new(bytes.Buffer)); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("head", func(t *testing.T) {
+		if exp, got := strings.Repeat(`{"name":"Joe","enum":3,"height":180}`+"\n", 2), runOK(t, "head", "-n", "2", "mem://test/todos.json.gz"); exp != got {
+			t.Errorf("expected:\n%s\ngot:\n%s", exp, got)
+		}
+	})
+
+	t.Run("count", func(t *testing.T) {
+		for url, exp := range map[string]string{
+			"mem://test/todos.json.gz": "3\n",
+			"mem://test/todos.cbor":    "2\n",
+			"mem://test/todos.pb.zst":  "4\n",
+		} {
+			if got := runOK(t, "count", url); exp != got {
+				t.Errorf("expected %q, got %q", exp, got)
+			}
+		}
+	})
+
+	t.Run("info", func(t *testing.T) {
+		got := runOK(t, "info", "mem://test/todos.pb.zst")
+		for _, exp := range []string{
+			"name:         todos.pb.zst\n",
+			"version:      103\n",
+			"format:       protobuf\n",
+			"compression:  zstd\n",
+		} {
+			if !strings.Contains(got, exp) {
+				t.Errorf("expected %q to contain %q", got, exp)
+			}
+		}
+	})
+
+	t.Run("manifest", func(t *testing.T) {
+		exp := `{
+  "version": 101,
+  "generation": 2,
+  "files": [
+    "data-0-100.json.gz",
+    "data-1-101.json.gz"
+  ]
+}
+`
+		if got := runOK(t, "manifest", "mem://test/manifest.json"); exp != got {
+			t.Errorf("expected:\n%s\ngot:\n%s", exp, got)
+		}
+		if err := run(ctx, []string{"manifest", "mem://test/missing.json"}, new(bytes.Buffer)); err != bfs.ErrNotFound {
+			t.Errorf("expected %v, got %v", bfs.ErrNotFound, err)
+		}
+	})
+
+	t.Run("protobuf", func(t *testing.T) {
+		fds := &descriptorpb.FileDescriptorSet{
+			File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(testdata.File_internal_testdata_testdata_proto)},
+		}
+		data, err := proto.Marshal(fds)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		descriptorSet := filepath.Join(t.TempDir(), "testdata.protoset")
+		if err := os.WriteFile(descriptorSet, data, 0o644); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		if exp, got := strings.Repeat(`{"name":"Joe","enum":3,"height":180}`+"\n", 2), runOK(t, "head", "-n", "2", "-type", "feedx.internal.testdata.MockMessage", "-descriptor-set", descriptorSet, "mem://test/todos.pb.zst"); exp != got {
+			t.Errorf("expected:\n%s\ngot:\n%s", exp, got)
+		}
+		if exp, got := "converted 4 records (version 103) to converted.ndjson\n", runOK(t, "convert", "-type", "feedx.internal.testdata.MockMessage", "-descriptor-set", descriptorSet, "mem://test/todos.pb.zst", "mem://test/converted.ndjson"); exp != got {
+			t.Errorf("expected %q, got %q", exp, got)
+		}
+		if exp, got := strings.Repeat(`{"name":"Joe","enum":3,"height":180}`+"\n", 4), runOK(t, "cat", "mem://test/converted.ndjson"); exp != got {
+			t.Errorf("expected:\n%s\ngot:\n%s", exp, got)
+		}
+		if exp, got := "version:      103\n", runOK(t, "info", "mem://test/converted.ndjson"); !strings.Contains(got, exp) {
+			t.Errorf("expected %q to contain %q", got, exp)
+		}
+
+		// compiled-in types are resolved from the global registry
+		if exp, got := "converted 3 records (version 101) to converted.pb.gz\n", runOK(t, "convert", "-type", "feedx.internal.testdata.MockMessage", "mem://test/todos.json.gz", "mem://test/converted.pb.gz"); exp != got {
+			t.Errorf("expected %q, got %q", exp, got)
+		}
+		if exp, got := "3\n", runOK(t, "count", "mem://test/converted.pb.gz"); exp != got {
+			t.Errorf("expected %q, got %q", exp, got)
+		}
+
+		for _, args := range [][]string{
+			{"convert", "mem://test/todos.pb.zst", "mem://test/failed.json"},
+			{"convert", "-type", "feedx.internal.testdata.Unknown", "-descriptor-set", descriptorSet, "mem://test/todos.pb.zst", "mem://test/failed.json"},
+			{"convert", "-descriptor-set", descriptorSet, "mem://test/todos.pb.zst", "mem://test/failed.json"},
+			{"convert", "mem://test/todos.pb.zst"},
+		} {
+			if err := run(ctx, args, new(bytes.Buffer)); err == nil {
+				t.Errorf("expected error for %v", args)
+			}
+		}
+	})
+
+	t.Run("local files", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "todos.ndjson")
+		if err := os.WriteFile(name, []byte(`{"a":1}`+"\n"+`{"b":2}`+"\n"), 0o644); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		if exp, got := "2\n", runOK(t, "count", name); exp != got {
+			t.Errorf("expected %q, got %q", exp, got)
+		}
+		if exp, got := `{"a":1}`+"\n", runOK(t, "head", "-n", "1", "file://"+name); exp != got {
+			t.Errorf("expected %q, got %q", exp, got)
+		}
+	})
+
+	t.Run("bad usage", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"unknown", "mem://test/todos.cbor"},
+			{"cat"},
+			{"cat", "-format", "xml", "mem://test/todos.cbor"},
+			{"count", "mem://test/manifest"},
+		} {
+			if err := run(ctx, args, new(bytes.Buffer)); err == nil {
+				t.Errorf("expected error for %v", args)
+			}
+		}
+	})
+}
+
+func writeFeed(t *testing.T, url string, numEntries int, version int64, extra ...any) {
+	t.Helper()
+
+	obj, err := bfs.NewObject(t.Context(), url)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	defer obj.Close()
+
+	w := feedx.NewWriter(context.Background(), obj, &feedx.WriterOptions{Version: version})
+	defer w.Discard()
+
+	for i := 0; i < numEntries; i++ {
+		if err := w.Encode(&testdata.MockMessage{Name: "Joe", Enum: testdata.MockEnum_FIRST, Height: 180}); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+	}
+	for _, v := range extra {
+		if err := w.Encode(v); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+	}
+	if err := w.Commit(); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func runOK(t *testing.T, args ...string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := run(t.Context(), args, &buf); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	return buf.String()
+}
+
+type testManifest struct {
+	Version    int64    `json:"version"`
+	Generation int      `json:"generation"`
+	Files      []string `json:"files"`
+}
+
+// memBuckets holds the in-memory buckets of mem:// test URLs, by host, so all
+// objects of a host share the same bucket for the lifetime of the process.
+var memBuckets = struct {
+	sync.Mutex
+	m map[string]*bfs.InMem
+}{m: make(map[string]*bfs.InMem)}
+
+func init() {
+	bfs.Register("mem", func(_ context.Context, u *url.URL) (bfs.Bucket, error) {
+		memBuckets.Lock()
+		defer memBuckets.Unlock()
+
+		bucket, ok := memBuckets.m[u.Host]
+		if !ok {
+			bucket = bfs.NewInMem()
+			memBuckets.m[u.Host] = bucket
+		}
+		return bucket, nil
+	})
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bsm/bfs"
+	_ "github.com/bsm/bfs/bfsfs"
+	"github.com/bsm/feedx"
+	"github.com/bsm/feedx/internal/recordjson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+var formats = map[string]feedx.Format{
+	"json":     feedx.JSONFormat,
+	"protobuf": feedx.ProtobufFormat,
+	"cbor":     feedx.CBORFormat,
+}
+
+var compressions = map[string]feedx.Compression{
+	"none":  feedx.NoCompression,
+	"gzip":  feedx.GZipCompression,
+	"flate": feedx.FlateCompression,
+	"zstd":  feedx.ZstdCompression,
+}
+
+func formatName(f feedx.Format) string {
+	for name, known := range formats {
+		if f == known {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+func compressionName(c feedx.Compression) string {
+	for name, known := range compressions {
+		if c == known {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// --------------------------------------------------------------------
+
+// readFlags holds the flags of the commands which read records.
+type readFlags struct {
+	format        string
+	compression   string
+	messageType   string
+	descriptorSet string
+}
+
+func (f *readFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.format, "format", "", "data format, one of "+strings.Join(sortedKeys(formats), ", ")+" (default: detected from URL)")
+	fs.StringVar(&f.compression, "compression", "", "compression, one of "+strings.Join(sortedKeys(compressions), ", ")+" (default: detected from URL)")
+	fs.StringVar(&f.messageType, "type", "", "full name of the protobuf message type, e.g. my.package.Message")
+	fs.StringVar(&f.descriptorSet, "descriptor-set", "", "path to a FileDescriptorSet file which contains the message type")
+}
+
+func (f *readFlags) readerOptions(name string) (*feedx.ReaderOptions, error) {
+	opt := &feedx.ReaderOptions{
+		Format:      feedx.DetectFormat(name),
+		Compression: feedx.DetectCompression(name),
+	}
+	if f.format != "" {
+		if opt.Format = formats[f.format]; opt.Format == nil {
+			return nil, fmt.Errorf("unknown format %q", f.format)
+		}
+	}
+	if f.compression != "" {
+		if opt.Compression = compressions[f.compression]; opt.Compression == nil {
+			return nil, fmt.Errorf("unknown compression %q", f.compression)
+		}
+	}
+	if formatName(opt.Format) == "unknown" {
+		return nil, fmt.Errorf("unable to detect format of %q, please specify -format", name)
+	}
+
+	msgType, err := f.resolveMessageType()
+	if err != nil {
+		return nil, err
+	}
+	opt.MessageType = msgType
+	return opt, nil
+}
+
+// resolveMessageType resolves the message type, either from the descriptor
+// set or from the global registry. Returns nil if no type is specified.
+func (f *readFlags) resolveMessageType() (protoreflect.MessageType, error) {
+	if f.messageType == "" {
+		if f.descriptorSet != "" {
+			return nil, errors.New("-descriptor-set requires a -type")
+		}
+		return nil, nil
+	}
+
+	var fds *descriptorpb.FileDescriptorSet
+	if f.descriptorSet != "" {
+		data, err := os.ReadFile(f.descriptorSet)
+		if err != nil {
+			return nil, err
+		}
+
+		fds = new(descriptorpb.FileDescriptorSet)
+		if err := proto.Unmarshal(data, fds); err != nil {
+			return nil, fmt.Errorf("invalid descriptor set %q: %w", f.descriptorSet, err)
+		}
+	}
+
+	msgType, err := feedx.ResolveMessageType(protoreflect.FullName(f.messageType), fds)
+	if err != nil {
+		return nil, fmt.Errorf("message type %q: %w", f.messageType, err)
+	}
+	return msgType, nil
+}
+
+// decodeRecord decodes the next record into a value that can be
+// marshaled as JSON.
+func decodeRecord(r *feedx.Reader, opt *feedx.ReaderOptions) (any, error) {
+	switch {
+	case opt.Format == feedx.JSONFormat:
+		var rec json.RawMessage
+		err := r.Decode(&rec)
+		return rec, err
+	case opt.Format == feedx.CBORFormat:
+		var rec any
+		err := r.Decode(&rec)
+		return recordjson.Normalize(rec), err
+	case opt.MessageType != nil:
+		var msg proto.Message
+		if err := r.Decode(&msg); err != nil {
+			return nil, err
+		}
+
+		return recordjson.Marshal(msg)
+	default:
+		// without a message type, unknown fields are retained but not
+		// interpreted, which is sufficient for counting
+		rec := new(emptypb.Empty)
+		err := r.Decode(rec)
+		return rec, err
+	}
+}
+
+// --------------------------------------------------------------------
+
+// openObject opens an object from a URL. URLs without a scheme are
+// treated as local file paths.
+func openObject(ctx context.Context, rawURL string) (*bfs.Object, error) {
+	if u, err := url.Parse(rawURL); err != nil || u.Scheme == "" {
+		abs, err := filepath.Abs(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		rawURL = "file://" + filepath.ToSlash(abs)
+	}
+	return bfs.NewObject(ctx, rawURL)
+}
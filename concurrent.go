@@ -0,0 +1,113 @@
+package feedx
+
+import (
+	"bytes"
+	"sync"
+)
+
+// ConcurrentWriterOptions configure the concurrent writer.
+type ConcurrentWriterOptions struct {
+	// ParallelEncoding validates and encodes values into per-goroutine
+	// buffers outside of the writer lock. Encoded values are then appended
+	// to the feed as whole records. This requires a format which encodes
+	// each value independently of the previous ones, which is true for all
+	// built-in formats.
+	// Default: false
+	ParallelEncoding bool
+}
+
+// ConcurrentWriter wraps a Writer and allows values to be encoded from
+// multiple goroutines. The underlying Writer must not be used directly
+// until all goroutines have finished.
+type ConcurrentWriter struct {
+	w   *Writer
+	opt ConcurrentWriterOptions
+	mu  sync.Mutex
+
+	encoders sync.Pool
+}
+
+// NewConcurrentWriter wraps a writer for concurrent use.
+func NewConcurrentWriter(w *Writer, opt *ConcurrentWriterOptions) *ConcurrentWriter {
+	var o ConcurrentWriterOptions
+	if opt != nil {
+		o = *opt
+	}
+
+	return &ConcurrentWriter{w: w, opt: o}
+}
+
+// Encode appends a value to the feed.
+func (w *ConcurrentWriter) Encode(v interface{}) error {
+	if !w.opt.ParallelEncoding {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		return w.w.Encode(v)
+	}
+
+	be, err := w.getEncoder()
+	if err != nil {
+		return err
+	}
+	defer w.encoders.Put(be)
+
+	be.buf.Reset()
+	invalid := w.w.validate(v)
+	if invalid == nil {
+		if err := be.enc.Encode(v); err != nil {
+			return err
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if ok, err := w.w.accept(invalid); !ok {
+		return err
+	}
+	return w.w.writeEncoded(v, be.buf.Bytes())
+}
+
+// Write writes raw bytes to the feed.
+func (w *ConcurrentWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.w.Write(p)
+}
+
+// NumWritten returns the number of written values.
+func (w *ConcurrentWriter) NumWritten() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.w.NumWritten()
+}
+
+// NumInvalid returns the number of values which failed validation.
+func (w *ConcurrentWriter) NumInvalid() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.w.NumInvalid()
+}
+
+func (w *ConcurrentWriter) getEncoder() (*bufferedEncoder, error) {
+	if be, ok := w.encoders.Get().(*bufferedEncoder); ok {
+		return be, nil
+	}
+
+	be := new(bufferedEncoder)
+	enc, err := w.w.opt.Format.NewEncoder(&be.buf)
+	if err != nil {
+		return nil, err
+	}
+	be.enc = enc
+	return be, nil
+}
+
+type bufferedEncoder struct {
+	buf bytes.Buffer
+	enc FormatEncoder
+}
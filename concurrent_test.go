@@ -0,0 +1,62 @@
+package feedx_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConcurrentWriter(t *testing.T) {
+	for _, name := range []string{"path/to/file.json", "path/to/file.pbz", "path/to/file.cbor"} {
+		for _, parallel := range []bool{false, true} {
+			obj := bfs.NewInMemObject(name)
+			defer obj.Close()
+
+			w := feedx.NewWriter(t.Context(), obj, &feedx.WriterOptions{Version: 101})
+			defer w.Discard()
+
+			cw := feedx.NewConcurrentWriter(w, &feedx.ConcurrentWriterOptions{ParallelEncoding: parallel})
+
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					for j := 0; j < 25; j++ {
+						if err := cw.Encode(seed()); err != nil {
+							t.Error("unexpected error", err)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			if exp, got := int64(200), cw.NumWritten(); exp != got {
+				t.Errorf("expected %v, got %v", exp, got)
+			}
+			if err := w.Commit(); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+
+			r, err := feedx.NewReader(t.Context(), obj, nil)
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			defer r.Close()
+
+			msgs := drainReader(t, r)
+			if exp, got := 200, len(msgs); exp != got {
+				t.Errorf("[%s, parallel:%v] expected %v, got %v", name, parallel, exp, got)
+			}
+			for _, msg := range msgs {
+				if exp := seed(); !proto.Equal(exp, msg) {
+					t.Fatalf("[%s, parallel:%v] expected %#v, got %#v", name, parallel, exp, msg)
+				}
+			}
+		}
+	}
+}
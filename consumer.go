@@ -3,7 +3,11 @@ package feedx
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/bsm/bfs"
 )
@@ -34,12 +38,13 @@ func NewConsumer(ctx context.Context, remoteURL string) (Consumer, error) {
 
 	csm := NewConsumerForRemote(remote)
 	csm.(*consumer).ownRemote = true
+	csm.(*consumer).feed = remoteURL
 	return csm, nil
 }
 
 // NewConsumerForRemote starts a new feed consumer with a remote.
 func NewConsumerForRemote(remote *bfs.Object) Consumer {
-	return &consumer{remote: remote}
+	return &consumer{remote: remote, feed: remote.Name()}
 }
 
 // NewIncrementalConsumer starts a new incremental feed consumer.
@@ -51,18 +56,62 @@ func NewIncrementalConsumer(ctx context.Context, bucketURL string) (Consumer, er
 
 	csm := NewIncrementalConsumerForBucket(bucket)
 	csm.(*consumer).ownBucket = true
+	csm.(*consumer).feed = bucketURL
 	return csm, nil
 }
 
 // NewIncrementalConsumerForBucket starts a new incremental feed consumer with a bucket.
+// Use ReaderOptions.Feed to label its syncs.
 func NewIncrementalConsumerForBucket(bucket bfs.Bucket) Consumer {
+	remote := bfs.NewObjectFromBucket(bucket, "manifest.json")
 	return &consumer{
-		remote:    bfs.NewObjectFromBucket(bucket, "manifest.json"),
+		remote:    remote,
 		ownRemote: true,
 		bucket:    bucket,
+		feed:      remote.Name(),
 	}
 }
 
+// NewPartitionedConsumer starts a new consumer for a partitioned feed. It only reads the partitions
+// assigned to the given index, where index is in the range [0, count).
+func NewPartitionedConsumer(ctx context.Context, bucketURL string, index, count int) (Consumer, error) {
+	if index < 0 || index >= count {
+		return nil, fmt.Errorf("feedx: invalid partition %d of %d", index, count)
+	}
+
+	bucket, err := bfs.Connect(ctx, bucketURL)
+	if err != nil {
+		return nil, err
+	}
+
+	csm, err := NewPartitionedConsumerForBucket(bucket, index, count)
+	if err != nil {
+		_ = bucket.Close()
+		return nil, err
+	}
+	csm.(*consumer).ownBucket = true
+	csm.(*consumer).feed = bucketURL
+	return csm, nil
+}
+
+// NewPartitionedConsumerForBucket starts a new partitioned feed consumer with a bucket.
+// Use ReaderOptions.Feed to label its syncs.
+func NewPartitionedConsumerForBucket(bucket bfs.Bucket, index, count int) (Consumer, error) {
+	if index < 0 || index >= count {
+		return nil, fmt.Errorf("feedx: invalid partition %d of %d", index, count)
+	}
+
+	remote := bfs.NewObjectFromBucket(bucket, "manifest.json")
+	return &consumer{
+		remote:         remote,
+		ownRemote:      true,
+		bucket:         bucket,
+		feed:           remote.Name(),
+		partition:      index,
+		partitionCount: count,
+	}, nil
+}
+
 type consumer struct {
 	remote    *bfs.Object
 	ownRemote bool
@@ -70,22 +119,62 @@ type consumer struct {
 	bucket    bfs.Bucket
 	ownBucket bool
 
+	feed string
+
+	partition      int
+	partitionCount int
+
 	version atomic.Int64
+
+	mu          sync.Mutex
+	lastSuccess time.Time
+	lastErr     error
 }
 
 // Consume implements Consumer interface.
 func (c *consumer) Consume(ctx context.Context, opt *ReaderOptions, fn ConsumeFunc) (*Status, error) {
+	start := time.Now()
+	feed := opt.feed(c.feed)
+	ctx, span := startSpan(ctx, "feedx.consume", slog.String("feedx.feed", feed), slog.Int64("feedx.local_version", c.Version()))
+	logger := opt.logger().With("feed", feed, "op", SyncOpConsume)
+	status, err := c.consume(ctx, opt, fn, logger)
+	status.finish(start)
+	logSync(logger, status, err)
+	endSyncSpan(span, status, err)
+	c.record(status, err)
+
+	event := SyncEvent{
+		Feed:       feed,
+		Op:         SyncOpConsume,
+		Duration:   status.Duration,
+		VersionLag: versionLag(status.RemoteVersion, c.Version()),
+	}
+	observeSync(opt.metrics(), &event, status, err)
+
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (c *consumer) consume(ctx context.Context, opt *ReaderOptions, fn ConsumeFunc, logger *slog.Logger) (*Status, error) {
 	localVersion := c.Version()
 	status := Status{
 		LocalVersion: localVersion,
 	}
 
 	// retrieve remote mtime
-	remoteVersion, err := fetchRemoteVersion(ctx, c.remote)
+	var remoteVersion int64
+	retries, err := opt.retryPolicy().do(ctx, func() (err error) {
+		remoteVersion, err = fetchRemoteVersion(ctx, c.remote)
+		return
+	})
+	status.Retries += retries
 	if err != nil {
-		return nil, err
+		return &status, err
 	}
 	status.RemoteVersion = remoteVersion
+	logger.Debug("fetched remote version", "local_version", localVersion, "remote_version", remoteVersion)
 
 	// skip sync unless modified
 	if skipSync(remoteVersion, localVersion) {
@@ -95,26 +184,44 @@ func (c *consumer) Consume(ctx context.Context, opt *ReaderOptions, fn ConsumeFu
 
 	var reader *Reader
 	if c.isIncremental() {
-		if reader, err = c.newIncrementalReader(ctx, opt); err != nil {
-			return nil, err
+		retries, err = opt.retryPolicy().do(ctx, func() (err error) {
+			reader, err = c.newIncrementalReader(ctx, opt, logger)
+			return
+		})
+		status.Retries += retries
+		if err != nil {
+			return &status, err
 		}
+		status.Objects = append(status.Objects, c.remote.Name())
 	} else {
 		if reader, err = NewReader(ctx, c.remote, opt); err != nil {
-			return nil, err
+			return &status, err
 		}
 	}
 	defer reader.Close()
+	reader.version = remoteVersion
 
 	// consume feed
-	if err := fn(reader); err != nil {
-		return nil, err
+	err = traceCallback(ctx, func() error { return fn(reader) })
+	status.addReader(reader)
+	if err != nil {
+		return &status, err
 	}
 
-	status.NumItems = reader.NumRead()
 	c.version.Store(remoteVersion)
 	return &status, nil
 }
 
+func (c *consumer) record(status *Status, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastErr = err
+	if err == nil {
+		c.lastSuccess = status.Finished
+	}
+}
+
 // Version implements Consumer interface.
 func (c *consumer) Version() int64 {
 	return c.version.Load()
@@ -141,18 +248,26 @@ func (c *consumer) isIncremental() bool {
 	return c.bucket != nil
 }
 
-func (c *consumer) newIncrementalReader(ctx context.Context, opt *ReaderOptions) (*Reader, error) {
+func (c *consumer) newIncrementalReader(ctx context.Context, opt *ReaderOptions, logger *slog.Logger) (*Reader, error) {
 	manifest, err := loadManifest(ctx, c.remote)
 	if err != nil {
 		return nil, err
 	}
+	logger.Debug("loaded manifest", "remote_version", manifest.Version, "files", len(manifest.Files))
 
 	files := manifest.Files
+	if c.partitionCount != 0 {
+		if files, err = manifest.partitionFiles(c.partition, c.partitionCount); err != nil {
+			return nil, err
+		}
+	}
+
 	remotes := make([]*bfs.Object, 0, len(files))
 	for _, file := range files {
 		remotes = append(remotes, bfs.NewObjectFromBucket(c.bucket, file))
 	}
 	r := MultiReader(ctx, remotes, opt)
 	r.ownRemotes = true
+	r.incremental = c.partitionCount == 0
 	return r, nil
 }
@@ -24,6 +24,11 @@ func TestConsumer(t *testing.T) {
 			RemoteVersion: 101,
 			Skipped:       false,
 			NumItems:      2,
+
+			NumBytes:           74,
+			NumCompressedBytes: 74,
+			NumFiles:           1,
+			Objects:            []string{"path/to/file.json"},
 		})
 		if exp, got := int64(101), csm.Version(); exp != got {
 			t.Errorf("expected %v, got %v", exp, got)
@@ -45,8 +50,15 @@ func TestConsumer(t *testing.T) {
 		csm := fixConsumer(t, 0)
 		defer csm.Close()
 
-		testConsume(t, csm, &feedx.Status{NumItems: 2})
-		testConsume(t, csm, &feedx.Status{NumItems: 2})
+		exp := &feedx.Status{
+			NumItems:           2,
+			NumBytes:           74,
+			NumCompressedBytes: 74,
+			NumFiles:           1,
+			Objects:            []string{"path/to/file.json"},
+		}
+		testConsume(t, csm, exp)
+		testConsume(t, csm, exp)
 	})
 
 	t.Run("incremental", func(t *testing.T) {
@@ -58,6 +70,11 @@ func TestConsumer(t *testing.T) {
 			LocalVersion:  0,
 			RemoteVersion: 101,
 			NumItems:      4,
+
+			NumBytes:           148,
+			NumCompressedBytes: 148,
+			NumFiles:           2,
+			Objects:            []string{"manifest.json", "data-0-0.json", "data-0-1.json"},
 		})
 		if exp, got := int64(101), csm.Version(); exp != got {
 			t.Errorf("expected %v, got %v", exp, got)
@@ -95,6 +112,15 @@ func fixConsumer(t *testing.T, version int64) feedx.Consumer {
 func fixIncrementalConsumer(t *testing.T, version int64) feedx.Consumer {
 	t.Helper()
 
+	csm := feedx.NewIncrementalConsumerForBucket(fixIncrementalBucket(t, version))
+	t.Cleanup(func() { _ = csm.Close() })
+
+	return csm
+}
+
+func fixIncrementalBucket(t *testing.T, version int64) bfs.Bucket {
+	t.Helper()
+
 	bucket := bfs.NewInMem()
 	obj1 := bfs.NewObjectFromBucket(bucket, "data-0-0.json")
 	if err := writeN(obj1, 2, 0); err != nil {
@@ -124,10 +150,7 @@ func fixIncrementalConsumer(t *testing.T, version int64) feedx.Consumer {
 		t.Fatal("unexpected error", err)
 	}
 
-	csm := feedx.NewIncrementalConsumerForBucket(bucket)
-	t.Cleanup(func() { _ = csm.Close() })
-
-	return csm
+	return bucket
 }
 
 func testConsume(t *testing.T, csm feedx.Consumer, exp *feedx.Status) (msgs []*testdata.MockMessage) {
@@ -141,7 +164,7 @@ func testConsume(t *testing.T, csm feedx.Consumer, exp *feedx.Status) (msgs []*t
 		t.Fatal("unexpected error", err)
 	}
 
-	if !reflect.DeepEqual(exp, status) {
+	if status := stripTimings(t, status); !reflect.DeepEqual(exp, status) {
 		t.Errorf("expected %#v, got %#v", exp, status)
 	}
 	return
@@ -0,0 +1,178 @@
+package feedx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx/internal/recordjson"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var errNoMessageType = errors.New("feedx: protobuf conversion requires a message type")
+
+// ConvertOptions configure feed conversion.
+type ConvertOptions struct {
+	// Reader configures the source reader.
+	// Default: format and compression are auto-detected from the source URL path.
+	Reader *ReaderOptions
+
+	// Writer configures the destination writer.
+	// Default: format and compression are auto-detected from the destination
+	// URL path, the version is copied from the source.
+	Writer *WriterOptions
+
+	// MessageType specifies the protobuf message type of the records.
+	// Required if either the source or the destination is a protobuf feed.
+	// Default: Reader.MessageType
+	MessageType protoreflect.MessageType
+}
+
+// Convert streams all records from src to dst, converting between formats
+// and compressions. Unless a Writer version is configured, the version of
+// src is preserved.
+//
+// Records are converted via their JSON representation, protobuf messages
+// are (un-)marshaled using protojson with original proto field names.
+func Convert(ctx context.Context, dst, src *bfs.Object, opt *ConvertOptions) (*Status, error) {
+	start := time.Now()
+
+	var o ConvertOptions
+	if opt != nil {
+		o = *opt
+	}
+
+	var ropt ReaderOptions
+	if o.Reader != nil {
+		ropt = *o.Reader
+	}
+	ropt.norm(src.Name())
+	if o.MessageType == nil {
+		o.MessageType = ropt.MessageType
+	}
+	ropt.MessageType = o.MessageType
+
+	var wopt WriterOptions
+	if o.Writer != nil {
+		wopt = *o.Writer
+	}
+	wopt.norm(dst.Name())
+
+	r, err := NewReader(ctx, src, &ropt)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	version, err := r.Version()
+	if err != nil {
+		return nil, err
+	}
+	if wopt.Version == 0 {
+		wopt.Version = version
+	}
+
+	conv := &recordConverter{src: ropt.Format, dst: wopt.Format, msgType: o.MessageType}
+	if (conv.src == ProtobufFormat || conv.dst == ProtobufFormat) && conv.msgType == nil {
+		return nil, errNoMessageType
+	}
+
+	w := NewWriter(ctx, dst, &wopt)
+	defer w.Discard()
+
+	status := &Status{RemoteVersion: version}
+	for {
+		rec, err := conv.Decode(r)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if rec, err = conv.Convert(rec); err != nil {
+			return nil, err
+		}
+		if err := w.Encode(rec); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.Close(); err != nil {
+		return nil, err
+	}
+	if err := w.Commit(); err != nil {
+		return nil, err
+	}
+
+	status.addWriter(w)
+	status.Retries = r.NumRetries()
+	status.finish(start)
+	return status, nil
+}
+
+// recordConverter decodes records from a src format and converts them to
+// values which can be encoded into a dst format.
+type recordConverter struct {
+	src, dst Format
+	msgType  protoreflect.MessageType
+}
+
+// Decode decodes the next record. JSON records are decoded as
+// json.RawMessage, protobuf records as messages of msgType, all other
+// records as generic values.
+func (c *recordConverter) Decode(r *Reader) (any, error) {
+	switch c.src {
+	case JSONFormat:
+		var rec json.RawMessage
+		err := r.Decode(&rec)
+		return rec, err
+	case ProtobufFormat:
+		var rec proto.Message
+		err := r.Decode(&rec)
+		return rec, err
+	default:
+		var rec any
+		err := r.Decode(&rec)
+		return recordjson.Normalize(rec), err
+	}
+}
+
+// Convert converts a decoded record for encoding into the dst format.
+func (c *recordConverter) Convert(rec any) (any, error) {
+	switch c.dst {
+	case ProtobufFormat:
+		if msg, ok := rec.(proto.Message); ok {
+			return msg, nil
+		}
+
+		data, err := recordjson.Marshal(rec)
+		if err != nil {
+			return nil, err
+		}
+
+		msg := c.msgType.New().Interface()
+		if err := protojson.Unmarshal(data, msg); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	case JSONFormat:
+		return recordjson.Marshal(rec)
+	default:
+		if _, ok := rec.(json.RawMessage); !ok {
+			if _, ok := rec.(proto.Message); !ok {
+				return rec, nil
+			}
+		}
+
+		data, err := recordjson.Marshal(rec)
+		if err != nil {
+			return nil, err
+		}
+		return recordjson.Unmarshal(data)
+	}
+}
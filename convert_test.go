@@ -0,0 +1,107 @@
+package feedx_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConvert(t *testing.T) {
+	bucket := bfs.NewInMem()
+	defer bucket.Close()
+
+	src := bfs.NewObjectFromBucket(bucket, "todos.json.gz")
+	if err := writeN(src, 3, 101); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+
+	msgType := seed().ProtoReflect().Type()
+	convert := func(t *testing.T, dst, src *bfs.Object) *feedx.Status {
+		t.Helper()
+
+		status, err := feedx.Convert(t.Context(), dst, src, &feedx.ConvertOptions{MessageType: msgType})
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		return status
+	}
+
+	t.Run("json to protobuf", func(t *testing.T) {
+		dst := bfs.NewObjectFromBucket(bucket, "todos.pb.zst")
+		status := convert(t, dst, src)
+		if exp, got := (&feedx.Status{
+			RemoteVersion:      101,
+			NumItems:           3,
+			NumBytes:           33,
+			NumCompressedBytes: 31,
+			NumFiles:           1,
+			Objects:            []string{"todos.pb.zst"},
+		}), stripTimings(t, status); !reflect.DeepEqual(exp, got) {
+			t.Errorf("expected %+v, got %+v", exp, got)
+		}
+
+		assertConverted(t, dst, 3, 101)
+	})
+
+	t.Run("protobuf to cbor to json", func(t *testing.T) {
+		cbor := bfs.NewObjectFromBucket(bucket, "todos.cbor")
+		convert(t, cbor, bfs.NewObjectFromBucket(bucket, "todos.pb.zst"))
+		assertConverted(t, cbor, 3, 101)
+
+		dst := bfs.NewObjectFromBucket(bucket, "todos.ndjson")
+		convert(t, dst, cbor)
+		assertConverted(t, dst, 3, 101)
+	})
+
+	t.Run("custom version", func(t *testing.T) {
+		dst := bfs.NewObjectFromBucket(bucket, "todos.v2.json")
+		if _, err := feedx.Convert(t.Context(), dst, src, &feedx.ConvertOptions{
+			Writer: &feedx.WriterOptions{Version: 202},
+		}); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		assertConverted(t, dst, 3, 202)
+	})
+
+	t.Run("no message type", func(t *testing.T) {
+		dst := bfs.NewObjectFromBucket(bucket, "todos.v3.pb")
+		if _, err := feedx.Convert(t.Context(), dst, src, nil); err == nil {
+			t.Fatal("expected error")
+		}
+		if _, err := dst.Head(t.Context()); err != bfs.ErrNotFound {
+			t.Errorf("expected %v, got %v", bfs.ErrNotFound, err)
+		}
+	})
+}
+
+func assertConverted(t *testing.T, obj *bfs.Object, numEntries int, version int64) {
+	t.Helper()
+
+	r, err := feedx.NewReader(t.Context(), obj, nil)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	defer r.Close()
+
+	if got, err := r.Version(); err != nil {
+		t.Fatal("unexpected error", err)
+	} else if got != version {
+		t.Errorf("expected %v, got %v", version, got)
+	}
+
+	msgs, err := readMessages(r)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if exp, got := numEntries, len(msgs); exp != got {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+	for _, msg := range msgs {
+		if !proto.Equal(seed(), msg) {
+			t.Errorf("expected %v, got %v", seed(), msg)
+		}
+	}
+}
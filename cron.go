@@ -0,0 +1,615 @@
+package feedx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CronFunc is a callback performed by a CronJob on every run.
+type CronFunc func(context.Context, *Job) (*Status, error)
+
+// CronOptions configure the scheduling of a CronJob.
+type CronOptions struct {
+	// RunOnStart performs the first run immediately instead of waiting
+	// for the first scheduled time.
+	// Default: false
+	RunOnStart bool
+
+	// Jitter delays each run by a random duration of up to the given
+	// value to spread load across replicas.
+	// Default: 0 (no jitter)
+	Jitter time.Duration
+
+	// Backoff delays the next run after a failed run. The delay doubles
+	// with every consecutive failure and never brings the next run
+	// forward of its scheduled time.
+	// Default: 0 (no backoff)
+	Backoff time.Duration
+
+	// MaxBackoff limits the delay after consecutive failures.
+	// Default: 0 (unlimited)
+	MaxBackoff time.Duration
+
+	// Timeout limits the runtime of each run.
+	// Default: 0 (no timeout)
+	Timeout time.Duration
+
+	// Name identifies the job in metrics.
+	// Default: "" (empty)
+	Name string
+
+	// Metrics records the outcome of each run.
+	// Default: nil (disabled)
+	Metrics Metrics
+
+	clock clock // replaced in tests
+}
+
+// delay returns the delay before the next run, given the delay until the
+// next scheduled time and the number of consecutive failures.
+func (o *CronOptions) delay(delay time.Duration, failures int) time.Duration {
+	if failures > 0 && o.Backoff > 0 {
+		backoff := shiftDuration(o.Backoff, failures-1)
+		if o.MaxBackoff > 0 && backoff > o.MaxBackoff {
+			backoff = o.MaxBackoff
+		}
+		if backoff > delay {
+			delay = backoff
+		}
+	}
+	if o.Jitter > 0 {
+		delay += rand.N(o.Jitter)
+	}
+	return delay
+}
+
+// CronResult holds the result of a single CronJob run.
+type CronResult struct {
+	// Status is the status returned by the run, may be nil.
+	Status *Status
+	// Err is the error returned by the run.
+	Err error
+	// Started is the start time of the run.
+	Started time.Time
+	// Finished is the end time of the run.
+	Finished time.Time
+}
+
+var errCronJobClosed = errors.New("feedx: cron job closed")
+
+// CronJob runs on a schedule until it's stopped.
+type CronJob struct {
+	cancel   context.CancelFunc
+	job      *Job
+	schedule schedule
+	opt      CronOptions
+	perform  CronFunc
+	wait     sync.WaitGroup
+	trigger  chan struct{}
+
+	mu          sync.RWMutex
+	last        *CronResult
+	lastSuccess *CronResult
+	nextRun     time.Time
+	subscribers map[chan CronResult]struct{}
+	closed      bool
+	ready       chan struct{} // closed after the first successful run which has loaded data
+	done        chan struct{} // closed when the job is closed
+}
+
+func newCronJob(job *Job, sched schedule, opt *CronOptions, perform CronFunc) *CronJob {
+	var o CronOptions
+	if opt != nil {
+		o = *opt
+	}
+	if o.clock == nil {
+		o.clock = realClock{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cron := &CronJob{
+		cancel:      cancel,
+		job:         job,
+		schedule:    sched,
+		opt:         o,
+		perform:     perform,
+		trigger:     make(chan struct{}, 1),
+		subscribers: make(map[chan CronResult]struct{}),
+		ready:       make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+
+	base := o.clock.Now()
+	next := base.Add(o.delay(0, 0))
+	if !o.RunOnStart {
+		base, next = cron.scheduleAfter(base, 0)
+	}
+	cron.setNextRun(next)
+
+	cron.wait.Add(1)
+	go cron.loop(ctx, base, next)
+	return cron
+}
+
+// Trigger requests an immediate run. If a run is currently in progress,
+// another run is started as soon as it completes. The schedule resumes
+// after the triggered run.
+func (j *CronJob) Trigger() {
+	select {
+	case j.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// LastStatus returns the status of the most recent run, may be nil.
+func (j *CronJob) LastStatus() *Status {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if j.last == nil {
+		return nil
+	}
+	return j.last.Status
+}
+
+// LastError returns the error of the most recent run.
+func (j *CronJob) LastError() error {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if j.last == nil {
+		return nil
+	}
+	return j.last.Err
+}
+
+// LastRun returns the start time of the most recent run. It returns a
+// zero time if the job has not run yet.
+func (j *CronJob) LastRun() time.Time {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if j.last == nil {
+		return time.Time{}
+	}
+	return j.last.Started
+}
+
+// LastSuccess returns the end time of the most recent successful run. It
+// returns a zero time if the job has not succeeded yet.
+func (j *CronJob) LastSuccess() time.Time {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	if j.lastSuccess == nil {
+		return time.Time{}
+	}
+	return j.lastSuccess.Finished
+}
+
+// WaitFirstSuccess blocks until the job has completed its first
+// successful run which has loaded data, e.g. until a consumer has
+// loaded the feed for the first time. Skipped runs only count if a
+// local version was present, e.g. if the consumer was already
+// loaded. If the context is cancelled or the job is closed before
+// that, it returns an error which wraps the error of the most recent
+// run, if any.
+func (j *CronJob) WaitFirstSuccess(ctx context.Context) error {
+	if j.isReady() {
+		return nil
+	}
+
+	select {
+	case <-j.ready:
+		return nil
+	case <-j.done:
+		return errors.Join(errCronJobClosed, j.LastError())
+	case <-ctx.Done():
+		return errors.Join(ctx.Err(), j.LastError())
+	}
+}
+
+// NextRun returns the time of the next scheduled run. It returns a zero
+// time if no further runs are scheduled.
+func (j *CronJob) NextRun() time.Time {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	return j.nextRun
+}
+
+// Subscribe returns a channel which receives the results of all
+// subsequent runs. Results are dropped if the buffer of the channel is
+// full. The channel is closed when the returned cancel function is
+// called or when the job is closed.
+func (j *CronJob) Subscribe(size int) (<-chan CronResult, func()) {
+	ch := make(chan CronResult, size)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	j.subscribers[ch] = struct{}{}
+	return ch, func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+
+		if _, ok := j.subscribers[ch]; ok {
+			delete(j.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close stops the job and waits until it is complete.
+func (j *CronJob) Close() error {
+	j.cancel()
+	j.wait.Wait()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.closed {
+		j.closed = true
+		close(j.done)
+	}
+	for ch := range j.subscribers {
+		delete(j.subscribers, ch)
+		close(ch)
+	}
+	return nil
+}
+
+func (j *CronJob) loop(ctx context.Context, base, next time.Time) {
+	defer j.wait.Done()
+
+	var failures int
+	for {
+		var timeout <-chan time.Time // blocks forever unless scheduled
+		if !next.IsZero() {
+			timeout = j.opt.clock.After(next.Sub(j.opt.clock.Now()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-timeout:
+		case <-j.trigger:
+			base = j.opt.clock.Now()
+		}
+
+		if err := j.run(ctx); err != nil {
+			failures++
+		} else {
+			failures = 0
+		}
+
+		base, next = j.scheduleAfter(base, failures)
+		j.setNextRun(next)
+	}
+}
+
+// scheduleAfter returns the scheduled time following base and the
+// actual time of the next run, after applying backoff and jitter. Runs
+// which were missed are performed immediately. It returns zero times if
+// no further runs are scheduled.
+func (j *CronJob) scheduleAfter(base time.Time, failures int) (time.Time, time.Time) {
+	scheduled := j.schedule.next(base)
+	if scheduled.IsZero() {
+		return scheduled, scheduled
+	}
+
+	now := j.opt.clock.Now()
+	if scheduled.Before(now) {
+		scheduled = now
+	}
+	return scheduled, now.Add(j.opt.delay(scheduled.Sub(now), failures))
+}
+
+func (j *CronJob) setNextRun(next time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.nextRun = next
+}
+
+func (j *CronJob) run(ctx context.Context) error {
+	if j.opt.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.opt.Timeout)
+		defer cancel()
+	}
+
+	res := CronResult{Started: j.opt.clock.Now()}
+	res.Status, res.Err = j.perform(ctx, j.job)
+	res.Finished = j.opt.clock.Now()
+	j.record(res)
+
+	event := SyncEvent{Feed: j.opt.Name, Op: SyncOpCron, Duration: res.Finished.Sub(res.Started)}
+	observeSync(j.opt.Metrics, &event, res.Status, res.Err)
+
+	return res.Err
+}
+
+func (j *CronJob) isReady() bool {
+	select {
+	case <-j.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+func (j *CronJob) record(res CronResult) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.last = &res
+	if res.Err == nil {
+		j.lastSuccess = &res
+
+		if res.Status.loaded() && !j.isReady() {
+			close(j.ready)
+		}
+	}
+	for ch := range j.subscribers {
+		select {
+		case ch <- res:
+		default:
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+
+// schedule determines the run times of a CronJob.
+type schedule interface {
+	// next returns the next run time after t.
+	next(t time.Time) time.Time
+}
+
+// clock provides the current time and timers.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// ----------------------------------------------------------------------------
+
+// intervalSchedule runs in fixed intervals.
+type intervalSchedule time.Duration
+
+func (s intervalSchedule) next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+// ----------------------------------------------------------------------------
+
+var (
+	cronMonthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	cronDayNames = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 0 1 1 *",
+		"@annually": "0 0 0 1 1 *",
+		"@monthly":  "0 0 0 1 * *",
+		"@weekly":   "0 0 0 * * 0",
+		"@daily":    "0 0 0 * * *",
+		"@midnight": "0 0 0 * * *",
+		"@hourly":   "0 0 * * * *",
+	}
+)
+
+// cronSchedule runs at times matching a cron expression.
+type cronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+
+	// domAny and dowAny are set if the day-of-month or day-of-week
+	// fields are unrestricted.
+	domAny, dowAny bool
+
+	loc *time.Location
+}
+
+// parseCronSchedule parses a standard cron expression with five fields
+// (minute, hour, day of month, month, day of week) or six fields, with
+// an additional leading seconds field. Expressions may be prefixed with
+// a time zone, e.g. "CRON_TZ=Europe/Berlin 5 * * * 1-5", and support
+// descriptors such as "@hourly" or "@daily".
+func parseCronSchedule(expr string) (*cronSchedule, error) {
+	spec := strings.TrimSpace(expr)
+	sched := &cronSchedule{loc: time.Local}
+
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		tz, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(tz, "=")
+
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("feedx: invalid cron expression %q: %w", expr, err)
+		}
+		sched.loc = loc
+		spec = strings.TrimSpace(rest)
+	}
+
+	if strings.HasPrefix(spec, "@") {
+		std, ok := cronDescriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("feedx: invalid cron expression %q: unknown descriptor", expr)
+		}
+		spec = std
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("feedx: invalid cron expression %q: expected 5 or 6 fields, got %d", expr, len(fields))
+	}
+
+	var err error
+	if sched.second, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("feedx: invalid cron expression %q: %w", expr, err)
+	}
+	if sched.minute, err = parseCronField(fields[1], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("feedx: invalid cron expression %q: %w", expr, err)
+	}
+	if sched.hour, err = parseCronField(fields[2], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("feedx: invalid cron expression %q: %w", expr, err)
+	}
+	if sched.dom, err = parseCronField(fields[3], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("feedx: invalid cron expression %q: %w", expr, err)
+	}
+	if sched.month, err = parseCronField(fields[4], 1, 12, cronMonthNames); err != nil {
+		return nil, fmt.Errorf("feedx: invalid cron expression %q: %w", expr, err)
+	}
+	if sched.dow, err = parseCronField(fields[5], 0, 7, cronDayNames); err != nil {
+		return nil, fmt.Errorf("feedx: invalid cron expression %q: %w", expr, err)
+	}
+
+	// 7 is an alias for sunday
+	if sched.dow&(1<<7) != 0 {
+		sched.dow = sched.dow&^(1<<7) | 1
+	}
+
+	sched.domAny = fields[3] == "*" || fields[3] == "?"
+	sched.dowAny = fields[5] == "*" || fields[5] == "?"
+	return sched, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges and
+// steps into a bit set.
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		expr, stepStr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if expr != "*" && expr != "?" {
+			loStr, hiStr, isRange := strings.Cut(expr, "-")
+
+			var err error
+			if lo, err = parseCronValue(loStr, names); err != nil {
+				return 0, err
+			}
+			if isRange {
+				if hi, err = parseCronValue(hiStr, names); err != nil {
+					return 0, err
+				}
+			} else if !hasStep {
+				hi = lo
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("value %q out of range [%d, %d]", part, min, max)
+		}
+		for i := lo; i <= hi; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(s string, names map[string]int) (int, error) {
+	if n, ok := names[strings.ToLower(s)]; ok {
+		return n, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return n, nil
+}
+
+func (s *cronSchedule) next(t time.Time) time.Time {
+	t = t.In(s.loc)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second()+1, 0, s.loc)
+
+	// search no further than 5 years ahead, e.g. for "0 0 30 2 *"
+	yearLimit := t.Year() + 5
+
+wrap:
+	for t.Year() <= yearLimit {
+		for s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.loc)
+			if t.Month() == time.January {
+				continue wrap
+			}
+		}
+
+		for !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.loc)
+			if t.Day() == 1 {
+				continue wrap
+			}
+		}
+
+		for s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.loc)
+			if t.Hour() == 0 {
+				continue wrap
+			}
+		}
+
+		for s.minute&(1<<uint(t.Minute())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, s.loc)
+			if t.Minute() == 0 {
+				continue wrap
+			}
+		}
+
+		for s.second&(1<<uint(t.Second())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second()+1, 0, s.loc)
+			if t.Second() == 0 {
+				continue wrap
+			}
+		}
+
+		return t
+	}
+	return time.Time{}
+}
+
+// matchDay follows the cron convention: if both day-of-month and
+// day-of-week are restricted, either of them needs to match.
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,219 @@
+package feedx_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bsm/feedx"
+)
+
+func TestCronSchedule(t *testing.T) {
+	now := time.Date(2024, 2, 28, 13, 7, 30, 0, time.UTC) // a Wednesday
+
+	for _, tc := range []struct {
+		expr string
+		exp  string
+	}{
+		{"* * * * *", "2024-02-28T13:08:00Z"},
+		{"*/15 * * * * *", "2024-02-28T13:07:45Z"},
+		{"5 * * * *", "2024-02-28T14:05:00Z"},
+		{"CRON_TZ=UTC 5 * * * MON-FRI", "2024-02-28T14:05:00Z"},
+		{"0 9 * * 1-5", "2024-02-29T09:00:00Z"},
+		{"0 9 * * sat,sun", "2024-03-02T09:00:00Z"},
+		{"0 0 29 2 *", "2024-02-29T00:00:00Z"},
+		{"0 0 1 jan *", "2025-01-01T00:00:00Z"},
+		{"0 0 1 * 0", "2024-03-01T00:00:00Z"},
+		{"0 0 * * 7", "2024-03-03T00:00:00Z"},
+		{"0 12-14/2 * * *", "2024-02-28T14:00:00Z"},
+		{"30 7 13 * * *", "2024-02-29T13:07:30Z"},
+		{"@hourly", "2024-02-28T14:00:00Z"},
+		{"@daily", "2024-02-29T00:00:00Z"},
+		{"TZ=Europe/Berlin 0 15 * * *", "2024-02-28T14:00:00Z"},
+	} {
+		got, err := feedx.NextCronTime(tc.expr, now)
+		if err != nil {
+			t.Errorf("[%s] unexpected error %v", tc.expr, err)
+		} else if got.UTC().Format(time.RFC3339) != tc.exp {
+			t.Errorf("[%s] expected %v, got %v", tc.expr, tc.exp, got.UTC().Format(time.RFC3339))
+		}
+	}
+
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"x * * * *",
+		"@never",
+		"CRON_TZ=Mars/Olympus * * * * *",
+	} {
+		if _, err := feedx.NextCronTime(expr, now); err == nil {
+			t.Errorf("[%s] expected error", expr)
+		}
+	}
+}
+
+func TestJob_RunOn(t *testing.T) {
+	t.Run("rejects invalid expressions", func(t *testing.T) {
+		if _, err := feedx.NewJob().RunOn("* * *", func(_ *feedx.Job) (*feedx.Status, error) {
+			return &feedx.Status{}, nil
+		}); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("runs", func(t *testing.T) {
+		numRuns := new(atomic.Int32)
+		cron, err := feedx.NewJob().RunOnWithOptions("@hourly", &feedx.CronOptions{
+			RunOnStart: true,
+		}, func(_ context.Context, _ *feedx.Job) (*feedx.Status, error) {
+			numRuns.Add(1)
+			return &feedx.Status{}, nil
+		})
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+		if err := cron.Close(); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp, got := int32(1), numRuns.Load(); exp != got {
+			t.Errorf("expected %d, got %d", exp, got)
+		}
+	})
+}
+
+func TestCronJob_Trigger(t *testing.T) {
+	numRuns := new(atomic.Int32)
+	cron := feedx.NewJob().RunEvery(time.Hour, func(_ *feedx.Job) (*feedx.Status, error) {
+		if numRuns.Add(1) == 2 {
+			return nil, errors.New("failed!")
+		}
+		return &feedx.Status{RemoteVersion: 101}, nil
+	})
+	defer cron.Close()
+
+	if got := cron.LastRun(); !got.IsZero() {
+		t.Errorf("expected zero time, got %v", got)
+	}
+	if got := cron.LastStatus(); got != nil {
+		t.Errorf("expected nil, got %v", got)
+	}
+	if next := cron.NextRun(); time.Until(next) < 59*time.Minute {
+		t.Errorf("expected next run in ~1h, got %v", next)
+	}
+
+	results, cancel := cron.Subscribe(2)
+	defer cancel()
+
+	// first run
+	cron.Trigger()
+	res := <-results
+	if res.Err != nil {
+		t.Fatal("unexpected error", res.Err)
+	}
+	if exp, got := int64(101), res.Status.RemoteVersion; exp != got {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+	if res.Started.IsZero() || res.Finished.Before(res.Started) {
+		t.Errorf("expected valid timestamps, got %v - %v", res.Started, res.Finished)
+	}
+	if exp, got := res.Started, cron.LastRun(); !exp.Equal(got) {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+
+	// second run
+	cron.Trigger()
+	if res := <-results; res.Err == nil {
+		t.Error("expected error")
+	}
+	if err := cron.LastError(); err == nil {
+		t.Error("expected error")
+	}
+	if got := cron.LastStatus(); got != nil {
+		t.Errorf("expected nil, got %v", got)
+	}
+	if exp, got := int32(2), numRuns.Load(); exp != got {
+		t.Errorf("expected %d, got %d", exp, got)
+	}
+
+	// close
+	if err := cron.Close(); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if _, ok := <-results; ok {
+		t.Error("expected channel to be closed")
+	}
+}
+
+func TestCronJob_WaitFirstSuccess(t *testing.T) {
+	t.Run("succeeds", func(t *testing.T) {
+		numRuns := new(atomic.Int32)
+		cron := feedx.NewJob().RunEveryWithOptions(time.Millisecond, &feedx.CronOptions{RunOnStart: true}, func(_ context.Context, _ *feedx.Job) (*feedx.Status, error) {
+			switch numRuns.Add(1) {
+			case 1:
+				return nil, errors.New("failed!")
+			case 2:
+				return &feedx.Status{Skipped: true}, nil
+			}
+			return &feedx.Status{RemoteVersion: 101}, nil
+		})
+		defer cron.Close()
+
+		if err := cron.WaitFirstSuccess(t.Context()); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if min, got := int32(3), numRuns.Load(); got < min {
+			t.Errorf("expected at least %v runs, got %v", min, got)
+		}
+
+		// returns immediately once ready
+		if err := cron.WaitFirstSuccess(t.Context()); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		exp := errors.New("failed!")
+		cron := feedx.NewJob().RunEveryWithOptions(time.Millisecond, &feedx.CronOptions{RunOnStart: true}, func(_ context.Context, _ *feedx.Job) (*feedx.Status, error) {
+			return nil, exp
+		})
+		defer cron.Close()
+
+		results, cancel := cron.Subscribe(1)
+		defer cancel()
+		<-results
+
+		ctx, cancelCtx := context.WithTimeout(t.Context(), 5*time.Millisecond)
+		defer cancelCtx()
+
+		err := cron.WaitFirstSuccess(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+		}
+		if !errors.Is(err, exp) {
+			t.Errorf("expected %v, got %v", exp, err)
+		}
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		cron := feedx.NewJob().RunEvery(time.Hour, func(_ *feedx.Job) (*feedx.Status, error) {
+			return &feedx.Status{}, nil
+		})
+		_ = cron.Close()
+
+		if err := cron.WaitFirstSuccess(t.Context()); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -0,0 +1,271 @@
+package feedx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/fxamacker/cbor/v2"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ChangeType identifies the type of a change between two snapshots.
+type ChangeType string
+
+// Supported change types.
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeChanged ChangeType = "changed"
+	ChangeRemoved ChangeType = "removed"
+)
+
+// Change describes a changed record between two snapshots of a feed.
+type Change[T any] struct {
+	// Type is the change type.
+	Type ChangeType
+	// Key is the record key.
+	Key string
+	// Old is the previous record, unset for ChangeAdded.
+	Old T
+	// New is the current record, unset for ChangeRemoved.
+	New T
+}
+
+// DiffOptions configure snapshot diffing.
+type DiffOptions struct {
+	// CachePath is the path of the local file which stores the previously
+	// consumed snapshot. Temporary files are created in the same directory.
+	// Required.
+	CachePath string
+
+	// MaxMemory limits the approximate number of bytes buffered in memory
+	// while sorting a snapshot. Larger snapshots are sorted externally, using
+	// temporary files.
+	// Default: 64 MiB
+	MaxMemory int
+}
+
+// ConsumeChanges returns a ConsumeFunc which compares each consumed snapshot
+// with the previously consumed one and passes the differences to fn, in key
+// order. Records are identified by key, if multiple records share a key, the
+// last one wins. On first consumption, all records are reported as added.
+//
+// The previous snapshot is cached locally and only replaced once all changes
+// have been passed to fn successfully, failed attempts report the same changes
+// again. Records are compared by their encoded representation: protobuf
+// messages are marshaled deterministically, other values are encoded as
+// canonical CBOR. Both Old and New values are decoded from that encoding.
+func ConsumeChanges[T any](opt *DiffOptions, key func(T) string, fn func(Change[T]) error) ConsumeFunc {
+	return func(r *Reader) error {
+		if opt == nil || opt.CachePath == "" {
+			return errors.New("feedx: diff requires a cache path")
+		}
+
+		var msgType protoreflect.MessageType
+		if r.opt != nil {
+			msgType = r.opt.MessageType
+		}
+
+		d := &differ[T]{
+			opt:    opt,
+			key:    key,
+			fn:     fn,
+			decode: newRecordDecoder[T](),
+			codec:  snapshotCodec{msgType: msgType},
+		}
+		return d.Consume(r)
+	}
+}
+
+type differ[T any] struct {
+	opt    *DiffOptions
+	key    func(T) string
+	fn     func(Change[T]) error
+	decode func(valueDecoder) (T, error)
+	codec  snapshotCodec
+}
+
+func (d *differ[T]) Consume(r *Reader) error {
+	dir := filepath.Dir(d.opt.CachePath)
+	sorter := newExtSorter(dir, d.opt.MaxMemory)
+	defer func() { _ = sorter.Close() }()
+
+	for v, err := range Records[T](r) {
+		if err != nil {
+			return err
+		}
+
+		data, err := d.codec.Encode(v)
+		if err != nil {
+			return err
+		}
+		if err := sorter.Add(d.key(v), data); err != nil {
+			return err
+		}
+	}
+
+	curr, err := sorter.Sort()
+	if err != nil {
+		return err
+	}
+
+	prev, err := os.Open(d.opt.CachePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return d.compare(dir, &sliceIterator{}, curr)
+	} else if err != nil {
+		return err
+	}
+	defer func() { _ = prev.Close() }()
+
+	return d.compare(dir, newEntryReader(prev), curr)
+}
+
+// compare merges the previous and current iterators, both in key order,
+// reports changes and writes the current snapshot to the cache.
+func (d *differ[T]) compare(dir string, prev, curr entryIterator) (err error) {
+	tmp, err := os.CreateTemp(dir, filepath.Base(d.opt.CachePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	w := newEntryWriter(tmp)
+	curr = &lastEntryIterator{it: curr}
+
+	po, pok, err := nextEntry(prev)
+	if err != nil {
+		return err
+	}
+	cn, cok, err := nextEntry(curr)
+	if err != nil {
+		return err
+	}
+
+	for pok || cok {
+		switch {
+		case !cok || (pok && po.key < cn.key):
+			if err := d.emit(ChangeRemoved, po, sortEntry{}); err != nil {
+				return err
+			}
+			if po, pok, err = nextEntry(prev); err != nil {
+				return err
+			}
+			continue
+
+		case !pok || cn.key < po.key:
+			if err := d.emit(ChangeAdded, sortEntry{}, cn); err != nil {
+				return err
+			}
+
+		default:
+			if !bytes.Equal(po.value, cn.value) {
+				if err := d.emit(ChangeChanged, po, cn); err != nil {
+					return err
+				}
+			}
+			if po, pok, err = nextEntry(prev); err != nil {
+				return err
+			}
+		}
+
+		if err := w.Write(cn); err != nil {
+			return err
+		}
+		if cn, cok, err = nextEntry(curr); err != nil {
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), d.opt.CachePath)
+}
+
+func (d *differ[T]) emit(typ ChangeType, prev, curr sortEntry) error {
+	change := Change[T]{Type: typ, Key: prev.key}
+	if typ != ChangeRemoved {
+		v, err := d.decode(d.codec.Decoder(curr.value))
+		if err != nil {
+			return err
+		}
+		change.Key, change.New = curr.key, v
+	}
+	if typ != ChangeAdded {
+		v, err := d.decode(d.codec.Decoder(prev.value))
+		if err != nil {
+			return err
+		}
+		change.Old = v
+	}
+	return d.fn(change)
+}
+
+func nextEntry(it entryIterator) (sortEntry, bool, error) {
+	e, err := it.Next()
+	if errors.Is(err, io.EOF) {
+		return sortEntry{}, false, nil
+	} else if err != nil {
+		return sortEntry{}, false, err
+	}
+	return e, true, nil
+}
+
+// --------------------------------------------------------------------
+
+var (
+	snapshotProtoOptions = proto.MarshalOptions{Deterministic: true}
+	snapshotCBORMode, _  = cbor.CoreDetEncOptions().EncMode()
+)
+
+// snapshotCodec encodes records deterministically.
+type snapshotCodec struct {
+	msgType protoreflect.MessageType
+}
+
+func (c snapshotCodec) Encode(v interface{}) ([]byte, error) {
+	if msg, ok := v.(proto.Message); ok {
+		return snapshotProtoOptions.Marshal(msg)
+	}
+	return snapshotCBORMode.Marshal(v)
+}
+
+func (c snapshotCodec) Decoder(data []byte) valueDecoder {
+	return snapshotDecoder{data: data, msgType: c.msgType}
+}
+
+type snapshotDecoder struct {
+	data    []byte
+	msgType protoreflect.MessageType
+}
+
+func (d snapshotDecoder) Decode(v interface{}) error {
+	switch vv := v.(type) {
+	case *proto.Message:
+		if d.msgType == nil {
+			return fmt.Errorf("feedx: cannot decode %T without a message type", v)
+		}
+		msg := d.msgType.New().Interface()
+		if err := proto.Unmarshal(d.data, msg); err != nil {
+			return err
+		}
+		*vv = msg
+		return nil
+	case proto.Message:
+		return proto.Unmarshal(d.data, vv)
+	default:
+		return cbor.Unmarshal(d.data, v)
+	}
+}
@@ -0,0 +1,172 @@
+package feedx_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+	"github.com/bsm/feedx/internal/testdata"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConsumeChanges(t *testing.T) {
+	type change struct {
+		Type     feedx.ChangeType
+		Key      string
+		Old, New uint32
+	}
+
+	// snapshot writes a feed version with records of name=height pairs.
+	snapshot := func(t *testing.T, obj *bfs.Object, version int64, heights map[string]uint32, names ...string) {
+		t.Helper()
+
+		w := feedx.NewWriter(t.Context(), obj, &feedx.WriterOptions{Version: version})
+		defer w.Discard()
+
+		for _, name := range names {
+			if err := w.Encode(&testdata.MockMessage{Name: name, Height: heights[name]}); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+	}
+
+	run := func(t *testing.T, ext string, opt *feedx.DiffOptions) {
+		obj := bfs.NewInMemObject("path/to/file." + ext)
+		defer obj.Close()
+
+		csm := feedx.NewConsumerForRemote(obj)
+		defer csm.Close()
+
+		var changes []change
+		var failWith error
+		consume := feedx.ConsumeChanges(opt, func(msg *testdata.MockMessage) string {
+			return msg.Name
+		}, func(c feedx.Change[*testdata.MockMessage]) error {
+			if failWith != nil {
+				return failWith
+			}
+			changes = append(changes, change{Type: c.Type, Key: c.Key, Old: c.Old.GetHeight(), New: c.New.GetHeight()})
+			return nil
+		})
+
+		snapshot(t, obj, 101, map[string]uint32{"a": 1, "b": 2, "c": 3}, "c", "a", "b")
+		if _, err := csm.Consume(t.Context(), nil, consume); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp := []change{
+			{Type: feedx.ChangeAdded, Key: "a", New: 1},
+			{Type: feedx.ChangeAdded, Key: "b", New: 2},
+			{Type: feedx.ChangeAdded, Key: "c", New: 3},
+		}; !reflect.DeepEqual(exp, changes) {
+			t.Errorf("expected %+v, got %+v", exp, changes)
+		}
+
+		// duplicate keys, last one wins
+		heights := map[string]uint32{"a": 1, "c": 4, "d": 5}
+		snapshot(t, obj, 102, heights, "d", "c", "a", "c")
+
+		failWith = errors.New("doh!")
+		if _, err := csm.Consume(t.Context(), nil, consume); !errors.Is(err, failWith) {
+			t.Fatalf("expected %v, got %v", failWith, err)
+		}
+
+		failWith, changes = nil, nil
+		if _, err := csm.Consume(t.Context(), nil, consume); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp := []change{
+			{Type: feedx.ChangeRemoved, Key: "b", Old: 2},
+			{Type: feedx.ChangeChanged, Key: "c", Old: 3, New: 4},
+			{Type: feedx.ChangeAdded, Key: "d", New: 5},
+		}; !reflect.DeepEqual(exp, changes) {
+			t.Errorf("expected %+v, got %+v", exp, changes)
+		}
+
+		changes = nil
+		snapshot(t, obj, 103, heights, "a", "c", "d")
+		if _, err := csm.Consume(t.Context(), nil, consume); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if len(changes) != 0 {
+			t.Errorf("expected no changes, got %+v", changes)
+		}
+	}
+
+	t.Run("protobuf", func(t *testing.T) {
+		run(t, "pb", &feedx.DiffOptions{CachePath: filepath.Join(t.TempDir(), "cache")})
+	})
+
+	t.Run("json", func(t *testing.T) {
+		run(t, "json", &feedx.DiffOptions{CachePath: filepath.Join(t.TempDir(), "cache")})
+	})
+
+	t.Run("external sort", func(t *testing.T) {
+		dir := t.TempDir()
+		run(t, "pb", &feedx.DiffOptions{CachePath: filepath.Join(dir, "cache"), MaxMemory: 1})
+
+		if matches, err := filepath.Glob(filepath.Join(dir, "*")); err != nil {
+			t.Fatal("unexpected error", err)
+		} else if exp := []string{filepath.Join(dir, "cache")}; !reflect.DeepEqual(exp, matches) {
+			t.Errorf("expected %v, got %v", exp, matches)
+		}
+	})
+
+	t.Run("large snapshots", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.pb")
+		defer obj.Close()
+
+		w := feedx.NewWriter(t.Context(), obj, &feedx.WriterOptions{Version: 101})
+		defer w.Discard()
+		for i := 999; i >= 0; i-- {
+			if err := w.Encode(&testdata.MockMessage{Name: fmt.Sprintf("%04d", i)}); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		r, err := feedx.NewReader(t.Context(), obj, nil)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		defer r.Close()
+
+		var keys []string
+		opt := &feedx.DiffOptions{CachePath: filepath.Join(t.TempDir(), "cache"), MaxMemory: 1024}
+		if err := feedx.ConsumeChanges(opt, (*testdata.MockMessage).GetName, func(c feedx.Change[*testdata.MockMessage]) error {
+			if !proto.Equal(c.New, &testdata.MockMessage{Name: c.Key}) {
+				return fmt.Errorf("unexpected record %v", c.New)
+			}
+			keys = append(keys, c.Key)
+			return nil
+		})(r); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		if exp, got := 1000, len(keys); exp != got {
+			t.Fatalf("expected %v, got %v", exp, got)
+		}
+		for i, key := range keys {
+			if exp := fmt.Sprintf("%04d", i); exp != key {
+				t.Fatalf("expected %v, got %v", exp, key)
+			}
+		}
+	})
+
+	t.Run("requires cache path", func(t *testing.T) {
+		r := fixReader(t)
+		if err := feedx.ConsumeChanges(nil, (*testdata.MockMessage).GetName, func(feedx.Change[*testdata.MockMessage]) error {
+			return nil
+		})(r); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
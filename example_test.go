@@ -96,13 +96,13 @@ func ExampleJob_ConsumeWith() {
 	if err != nil {
 		panic(err)
 	}
-	fmt.Printf("4. Result - %+v\n", *status)
+	fmt.Printf("4. Result - skipped:%v version:%v->%v items:%v\n", status.Skipped, status.LocalVersion, status.RemoteVersion, status.NumItems)
 
 	// Output:
 	// 1. Before sync
 	// 2. Consuming feed
 	// 3. After sync - error:<nil>
-	// 4. Result - {Skipped:false LocalVersion:0 RemoteVersion:0 NumItems:0}
+	// 4. Result - skipped:false version:0->0 items:0
 }
 
 func ExampleJob_ProduceWith() {
@@ -135,14 +135,14 @@ func ExampleJob_ProduceWith() {
 	if err != nil {
 		panic(err)
 	}
-	fmt.Printf("5. Result - %+v\n", *status)
+	fmt.Printf("5. Result - skipped:%v version:%v->%v items:%v\n", status.Skipped, status.LocalVersion, status.RemoteVersion, status.NumItems)
 
 	// Output:
 	// 1. Retrieve latest version
 	// 2. Before sync
 	// 3. Producing feed
 	// 4. After sync - error:<nil>
-	// 5. Result - {Skipped:false LocalVersion:101 RemoteVersion:0 NumItems:0}
+	// 5. Result - skipped:false version:101->0 items:0
 }
 
 func ExampleCronJob() {
@@ -180,3 +180,43 @@ func ExampleCronJob() {
 	// 2. Consuming feed
 	// 3. After sync - error:<nil>
 }
+
+func ExampleConsumeRecords() {
+	ctx := context.TODO()
+
+	// create an mock object
+	obj := bfs.NewInMemObject("todos.ndjson")
+	defer obj.Close()
+
+	pcr := feedx.NewProducerForRemote(obj)
+	defer pcr.Close()
+
+	// produce
+	if _, err := pcr.Produce(ctx, 101, nil, func(w *feedx.Writer) error {
+		return errors.Join(
+			w.Encode(&message{Name: "Jane", Height: 175}),
+			w.Encode(&message{Name: "Joe", Height: 172}),
+		)
+	}); err != nil {
+		panic(err)
+	}
+
+	// create a consumer
+	csm := feedx.NewConsumerForRemote(obj)
+	defer csm.Close()
+
+	// consume typed records
+	status, err := csm.Consume(ctx, nil, feedx.ConsumeRecords(func(msg *message) error {
+		fmt.Printf("DATA     %q\n", msg.Name)
+		return nil
+	}))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("CONSUMED items:%v\n", status.NumItems)
+
+	// Output:
+	// DATA     "Jane"
+	// DATA     "Joe"
+	// CONSUMED items:2
+}
@@ -0,0 +1,384 @@
+package feedx
+
+import (
+	"bufio"
+	"cmp"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"slices"
+)
+
+// sortEntry is a key/value pair processed by the external sorter.
+type sortEntry struct {
+	key   string
+	value []byte
+}
+
+func (e sortEntry) size() int { return len(e.key) + len(e.value) + 48 }
+
+// extSorter sorts entries by key using external merge sort. Entries are
+// buffered in memory up to maxBytes, then spilled as sorted runs to
+// temporary files in dir. Runs are merged in batches of up to maxRuns, so
+// the number of open files remains bounded. Entries with equal keys retain
+// their insertion order.
+type extSorter struct {
+	dir      string
+	maxBytes int
+	maxRuns  int
+
+	buf   []sortEntry
+	size  int
+	runs  []string   // closed run files, in insertion order
+	files []*os.File // runs opened by Sort
+}
+
+const (
+	// defaultSortMemory is the default number of bytes buffered by extSorter.
+	defaultSortMemory = 64 << 20
+	// defaultSortRuns is the default maximum number of runs merged at once.
+	defaultSortRuns = 64
+)
+
+func newExtSorter(dir string, maxBytes int) *extSorter {
+	if maxBytes <= 0 {
+		maxBytes = defaultSortMemory
+	}
+	return &extSorter{dir: dir, maxBytes: maxBytes, maxRuns: defaultSortRuns}
+}
+
+// Add adds an entry.
+func (s *extSorter) Add(key string, value []byte) error {
+	e := sortEntry{key: key, value: value}
+	s.buf = append(s.buf, e)
+	s.size += e.size()
+	if s.size >= s.maxBytes {
+		return s.spill()
+	}
+	return nil
+}
+
+// NumRuns returns the number of runs spilled to disk.
+func (s *extSorter) NumRuns() int { return len(s.runs) }
+
+// Sort returns an iterator over all entries, in key order. The sorter must
+// not be modified after calling Sort.
+func (s *extSorter) Sort() (entryIterator, error) {
+	s.sortBuffer()
+	if len(s.runs) == 0 {
+		return &sliceIterator{entries: s.buf}, nil
+	}
+
+	// reserve one slot for the buffer
+	for len(s.runs) > s.maxRuns-1 {
+		if err := s.mergePass(); err != nil {
+			return nil, err
+		}
+	}
+
+	iters := make([]entryIterator, 0, len(s.runs)+1)
+	for _, name := range s.runs {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		s.files = append(s.files, f)
+		iters = append(iters, newEntryReader(f))
+	}
+	iters = append(iters, &sliceIterator{entries: s.buf})
+	return newMergeIterator(iters)
+}
+
+// Close removes all temporary files.
+func (s *extSorter) Close() (err error) {
+	for _, f := range s.files {
+		err = errors.Join(err, f.Close())
+	}
+	for _, name := range s.runs {
+		err = errors.Join(err, os.Remove(name))
+	}
+	s.files = nil
+	s.runs = nil
+	s.buf = nil
+	return
+}
+
+func (s *extSorter) sortBuffer() {
+	slices.SortStableFunc(s.buf, func(a, b sortEntry) int { return cmp.Compare(a.key, b.key) })
+}
+
+func (s *extSorter) spill() error {
+	s.sortBuffer()
+	if err := s.writeRun(&sliceIterator{entries: s.buf}); err != nil {
+		return err
+	}
+
+	s.buf = s.buf[:0]
+	s.size = 0
+	return nil
+}
+
+// mergePass merges consecutive batches of runs into single runs.
+func (s *extSorter) mergePass() error {
+	runs := s.runs
+	s.runs = nil
+	for i := 0; i < len(runs); i += s.maxRuns {
+		batch := runs[i:min(i+s.maxRuns, len(runs))]
+		if len(batch) == 1 {
+			s.runs = append(s.runs, batch[0])
+			continue
+		}
+
+		if err := s.mergeRuns(batch); err != nil {
+			s.runs = append(s.runs, runs[i:]...)
+			return err
+		}
+
+		// the batch is merged, keep only the unmerged remainder on failure
+		var err error
+		for _, name := range batch {
+			err = errors.Join(err, os.Remove(name))
+		}
+		if err != nil {
+			s.runs = append(s.runs, runs[i+len(batch):]...)
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeRuns merges a batch of runs into a new run.
+func (s *extSorter) mergeRuns(batch []string) error {
+	iters := make([]entryIterator, 0, len(batch))
+	for _, name := range batch {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+
+		iters = append(iters, newEntryReader(f))
+	}
+
+	it, err := newMergeIterator(iters)
+	if err != nil {
+		return err
+	}
+	return s.writeRun(it)
+}
+
+// writeRun writes entries to a new run file.
+func (s *extSorter) writeRun(it entryIterator) (err error) {
+	f, err := os.CreateTemp(s.dir, "feedx-sort-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := f.Close(); e != nil && err == nil {
+			err = e
+		}
+		if err != nil {
+			_ = os.Remove(f.Name())
+		} else {
+			s.runs = append(s.runs, f.Name())
+		}
+	}()
+
+	w := newEntryWriter(f)
+	for {
+		e, err := it.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+		if err := w.Write(e); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// --------------------------------------------------------------------
+
+// entryIterator iterates over entries. Next returns io.EOF once exhausted.
+type entryIterator interface {
+	Next() (sortEntry, error)
+}
+
+type sliceIterator struct {
+	entries []sortEntry
+	pos     int
+}
+
+func (it *sliceIterator) Next() (sortEntry, error) {
+	if it.pos >= len(it.entries) {
+		return sortEntry{}, io.EOF
+	}
+	e := it.entries[it.pos]
+	it.pos++
+	return e, nil
+}
+
+// lastEntryIterator wraps a sorted iterator and skips all but the last of
+// consecutive entries with equal keys.
+type lastEntryIterator struct {
+	it   entryIterator
+	next *sortEntry
+}
+
+func (l *lastEntryIterator) Next() (sortEntry, error) {
+	if l.next == nil {
+		e, err := l.it.Next()
+		if err != nil {
+			return sortEntry{}, err
+		}
+		l.next = &e
+	}
+
+	for {
+		e, err := l.it.Next()
+		if errors.Is(err, io.EOF) {
+			last := *l.next
+			l.next = nil
+			return last, nil
+		} else if err != nil {
+			return sortEntry{}, err
+		}
+
+		if e.key != l.next.key {
+			last := *l.next
+			l.next = &e
+			return last, nil
+		}
+		l.next = &e
+	}
+}
+
+// entryWriter writes length-prefixed entries.
+type entryWriter struct {
+	w   *bufio.Writer
+	tmp []byte
+}
+
+func newEntryWriter(w io.Writer) *entryWriter {
+	return &entryWriter{w: bufio.NewWriter(w)}
+}
+
+func (w *entryWriter) Write(e sortEntry) error {
+	w.tmp = binary.AppendUvarint(w.tmp[:0], uint64(len(e.key)))
+	w.tmp = append(w.tmp, e.key...)
+	w.tmp = binary.AppendUvarint(w.tmp, uint64(len(e.value)))
+	if _, err := w.w.Write(w.tmp); err != nil {
+		return err
+	}
+	_, err := w.w.Write(e.value)
+	return err
+}
+
+func (w *entryWriter) Flush() error { return w.w.Flush() }
+
+// entryReader reads entries written by an entryWriter.
+type entryReader struct {
+	r *bufio.Reader
+}
+
+func newEntryReader(r io.Reader) *entryReader {
+	return &entryReader{r: bufio.NewReader(r)}
+}
+
+func (r *entryReader) Next() (sortEntry, error) {
+	key, err := r.readBytes()
+	if err != nil {
+		return sortEntry{}, err
+	}
+
+	value, err := r.readBytes()
+	if errors.Is(err, io.EOF) {
+		return sortEntry{}, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return sortEntry{}, err
+	}
+	return sortEntry{key: string(key), value: value}, nil
+}
+
+func (r *entryReader) readBytes() ([]byte, error) {
+	n, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// mergeIterator merges multiple sorted iterators. Entries with equal keys
+// are returned in the order of their iterators.
+type mergeIterator struct {
+	h mergeHeap
+}
+
+func newMergeIterator(iters []entryIterator) (*mergeIterator, error) {
+	m := &mergeIterator{h: make(mergeHeap, 0, len(iters))}
+	for i, it := range iters {
+		if err := m.push(i, it); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *mergeIterator) Next() (sortEntry, error) {
+	if len(m.h) == 0 {
+		return sortEntry{}, io.EOF
+	}
+
+	item := heap.Pop(&m.h).(mergeItem)
+	if err := m.push(item.index, item.iter); err != nil {
+		return sortEntry{}, err
+	}
+	return item.entry, nil
+}
+
+func (m *mergeIterator) push(index int, it entryIterator) error {
+	e, err := it.Next()
+	if errors.Is(err, io.EOF) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	heap.Push(&m.h, mergeItem{entry: e, index: index, iter: it})
+	return nil
+}
+
+type mergeItem struct {
+	entry sortEntry
+	index int
+	iter  entryIterator
+}
+
+type mergeHeap []mergeItem
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if c := cmp.Compare(h[i].entry.key, h[j].entry.key); c != 0 {
+		return c < 0
+	}
+	return h[i].index < h[j].index
+}
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x any)   { *h = append(*h, x.(mergeItem)) }
+func (h *mergeHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
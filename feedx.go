@@ -3,7 +3,9 @@ package feedx
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"strconv"
+	"time"
 
 	"github.com/bsm/bfs"
 )
@@ -13,7 +15,10 @@ var ErrNotModified = errors.New("feedx: not modified")
 
 const metaVersion = "X-Feedx-Version"
 
-func fetchRemoteVersion(ctx context.Context, obj *bfs.Object) (int64, error) {
+func fetchRemoteVersion(ctx context.Context, obj *bfs.Object) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "feedx.fetch_remote_version", slog.String("feedx.object", obj.Name()))
+	defer func() { endSpan(span, err) }()
+
 	info, err := obj.Head(ctx)
 	if err == bfs.ErrNotFound {
 		return 0, nil
@@ -35,6 +40,59 @@ type Status struct {
 	RemoteVersion int64
 	// NumItems returns the number of items processed, either read of written.
 	NumItems int64
+	// NumInvalidItems returns the number of items which failed validation and were not written.
+	NumInvalidItems int64
+	// Retries indicates the number of retries performed after failed remote operations.
+	Retries int
+
+	// Started indicates the time the sync was started.
+	Started time.Time
+	// Finished indicates the time the sync was finished.
+	Finished time.Time
+	// Duration indicates the total duration of the sync.
+	Duration time.Duration
+
+	// NumBytes returns the number of uncompressed bytes processed, either read or written.
+	NumBytes int64
+	// NumCompressedBytes returns the number of compressed bytes transferred from or to the remote.
+	NumCompressedBytes int64
+	// NumFiles returns the number of remote data files processed, either read or written.
+	NumFiles int
+	// Objects contains the names of the remote objects read or written, including manifests.
+	Objects []string
+}
+
+func (s *Status) addReader(r *Reader) {
+	s.NumItems += r.NumRead()
+	s.NumBytes += r.NumBytes()
+	s.NumCompressedBytes += r.NumCompressedBytes()
+	s.NumFiles += r.NumFiles()
+	s.Retries += r.NumRetries()
+	s.Objects = append(s.Objects, r.opened...)
+}
+
+func (s *Status) addWriter(w *Writer) {
+	s.NumItems += w.NumWritten()
+	s.NumInvalidItems += w.NumInvalid()
+	s.NumBytes += w.NumBytes()
+	s.NumCompressedBytes += w.NumCompressedBytes()
+	if w.bw != nil {
+		s.NumFiles++
+		s.Objects = append(s.Objects, w.remote.Name())
+	}
+}
+
+func (s *Status) finish(started time.Time) {
+	s.Started = started
+	s.Finished = time.Now()
+	s.Duration = s.Finished.Sub(started)
+}
+
+// loaded reports whether a successful sync has left data in place. Skipped
+// syncs only count if a local version is present. Nil statuses are
+// reported by custom cron functions and count as loaded.
+func (s *Status) loaded() bool {
+	return s == nil || !s.Skipped || s.LocalVersion != 0
 }
 
 func skipSync(srcVersion, targetVersion int64) bool {
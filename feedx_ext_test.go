@@ -2,6 +2,12 @@ package feedx
 
 import (
 	"context"
+	"errors"
+	"expvar"
+	"io"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/bsm/bfs"
 )
@@ -14,3 +20,122 @@ func LoadManifest(ctx context.Context, obj *bfs.Object) (*Manifest, error) {
 	m, err := loadManifest(ctx, obj)
 	return (*Manifest)(m), err
 }
+
+func NextCronTime(expr string, t time.Time) (time.Time, error) {
+	sched, err := parseCronSchedule(expr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return sched.next(t), nil
+}
+
+func RetryBackoff(p *RetryPolicy, retry int) time.Duration {
+	return p.backoff(retry)
+}
+
+func IsRetryable(p *RetryPolicy, err error) bool {
+	return p.retryable(err)
+}
+
+func PermanentError(err error) error {
+	return permanentError{err}
+}
+
+func NewExpvarMetricsMap(root *expvar.Map) *ExpvarMetrics {
+	return newExpvarMetrics(root)
+}
+
+func WithCronClock(opt *CronOptions, now func() time.Time, after func(time.Duration) <-chan time.Time) *CronOptions {
+	opt.clock = funcClock{now: now, after: after}
+	return opt
+}
+
+type funcClock struct {
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+}
+
+func (c funcClock) Now() time.Time                         { return c.now() }
+func (c funcClock) After(d time.Duration) <-chan time.Time { return c.after(d) }
+
+const SSTIndexInterval = sstIndexInterval
+
+var ErrBadTable = errBadTable
+
+type SSTReader = sstReader
+
+func WriteSSTable(name string, version int64, keys ...string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := newSSTWriter(f, len(keys))
+	for _, key := range keys {
+		if err := w.Add(sortEntry{key: key, value: []byte("v" + key)}); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(version); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func OpenSSTable(name string) (*SSTReader, error) {
+	return openSSTReader(name)
+}
+
+func ScanSSTable(r *SSTReader, start, end string) ([]string, error) {
+	var keys []string
+	err := r.Scan(start, end, func(e sortEntry) bool {
+		keys = append(keys, e.key)
+		return true
+	})
+	return keys, err
+}
+
+func SSTableInfo(r *SSTReader) (num, version int64) {
+	return r.num, r.version
+}
+
+func NewBloomFilter(keys ...string) func(string) bool {
+	b := newBloomFilter(len(keys))
+	for _, key := range keys {
+		b.Add(key)
+	}
+	return b.Contains
+}
+
+// ExtSort sorts keys with the given limits, it returns the sorted keys with
+// their insertion positions and the number of runs merged by the final pass.
+func ExtSort(dir string, maxBytes, maxRuns int, keys ...string) ([]string, int, error) {
+	s := newExtSorter(dir, maxBytes)
+	s.maxRuns = maxRuns
+	defer s.Close()
+
+	for i, key := range keys {
+		if err := s.Add(key, []byte(strconv.Itoa(i))); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	it, err := s.Sort()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var res []string
+	for {
+		e, err := it.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, 0, err
+		}
+		res = append(res, e.key+"@"+string(e.value))
+	}
+	numFiles := len(s.files)
+	return res, numFiles, s.Close()
+}
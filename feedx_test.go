@@ -3,12 +3,34 @@ package feedx_test
 import (
 	"context"
 	"io"
+	"testing"
+	"time"
 
 	"github.com/bsm/bfs"
 	"github.com/bsm/feedx"
 	"github.com/bsm/feedx/internal/testdata"
 )
 
+// stripTimings verifies the timings of a status and resets them for
+// comparison.
+func stripTimings(t *testing.T, status *feedx.Status) *feedx.Status {
+	t.Helper()
+
+	if status == nil {
+		return nil
+	}
+	if status.Started.IsZero() || status.Finished.Before(status.Started) {
+		t.Errorf("expected valid timings, got %v - %v", status.Started, status.Finished)
+	}
+	if exp, got := status.Finished.Sub(status.Started), status.Duration; exp != got {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+
+	res := *status
+	res.Started, res.Finished, res.Duration = time.Time{}, time.Time{}, 0
+	return &res
+}
+
 func seed() *testdata.MockMessage {
 	return &testdata.MockMessage{
 		Name:   "Joe",
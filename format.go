@@ -10,6 +10,11 @@ import (
 	"github.com/bsm/pbio"
 	"github.com/fxamacker/cbor/v2"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
 )
 
 var errNoFormat = errors.New("feedx: no format detected")
@@ -146,6 +151,32 @@ func (*protobufWrapper) Close() error {
 	return nil
 }
 
+// ResolveMessageType resolves a protobuf message type by its full name. If
+// a FileDescriptorSet is given, the type is built from the descriptors and
+// yields dynamicpb messages, otherwise it is looked up in
+// protoregistry.GlobalTypes.
+func ResolveMessageType(name protoreflect.FullName, fds *descriptorpb.FileDescriptorSet) (protoreflect.MessageType, error) {
+	if fds == nil {
+		return protoregistry.GlobalTypes.FindMessageByName(name)
+	}
+
+	files, err := protodesc.NewFiles(fds)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := files.FindDescriptorByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	msgDesc, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("feedx: %s is not a message type", name)
+	}
+	return dynamicpb.NewMessageType(msgDesc), nil
+}
+
 // --------------------------------------------------------------------
 
 // CBORFormat provides a Format implemention for CBOR.
@@ -8,6 +8,10 @@ import (
 
 	"github.com/bsm/feedx"
 	"github.com/bsm/feedx/internal/testdata"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
 )
 
 func TestDetectFormat(t *testing.T) {
@@ -53,6 +57,33 @@ func TestFormat(t *testing.T) {
 	})
 }
 
+func TestResolveMessageType(t *testing.T) {
+	fds := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(testdata.File_internal_testdata_testdata_proto)},
+	}
+
+	if msgType, err := feedx.ResolveMessageType("feedx.internal.testdata.MockMessage", fds); err != nil {
+		t.Fatal("unexpected error", err)
+	} else if _, ok := msgType.New().Interface().(*dynamicpb.Message); !ok {
+		t.Errorf("expected a dynamic message type, got %T", msgType.New().Interface())
+	}
+
+	if msgType, err := feedx.ResolveMessageType("feedx.internal.testdata.MockMessage", nil); err != nil {
+		t.Fatal("unexpected error", err)
+	} else if _, ok := msgType.New().Interface().(*testdata.MockMessage); !ok {
+		t.Errorf("expected a compiled message type, got %T", msgType.New().Interface())
+	}
+
+	for _, name := range []protoreflect.FullName{
+		"feedx.internal.testdata.Unknown",
+		"feedx.internal.testdata.MockEnum",
+	} {
+		if _, err := feedx.ResolveMessageType(name, fds); err == nil {
+			t.Errorf("expected error for %s", name)
+		}
+	}
+}
+
 func testFormat(t *testing.T, f feedx.Format) {
 	t.Helper()
 
@@ -0,0 +1,196 @@
+package feedx
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthOptions configure the health check of a feed.
+type HealthOptions struct {
+	// MaxStaleness marks a feed as unhealthy if it has not been synced
+	// successfully within the given duration.
+	// Default: 0 (never stale)
+	MaxStaleness time.Duration
+}
+
+// HealthReport reports the health of all registered feeds.
+type HealthReport struct {
+	// Healthy is true if all feeds are healthy.
+	Healthy bool `json:"healthy"`
+	// Feeds contains the health of each feed, by name.
+	Feeds map[string]*FeedHealth `json:"feeds"`
+}
+
+// FeedHealth reports the health of a single feed.
+type FeedHealth struct {
+	// Healthy is true if the feed was synced successfully within the
+	// configured staleness threshold.
+	Healthy bool `json:"healthy"`
+	// Reason explains why the feed is unhealthy.
+	Reason string `json:"reason,omitempty"`
+	// Version is the most recently synced version.
+	Version int64 `json:"version"`
+	// LastSuccess is the time of the most recent successful sync.
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	// LastError is the error of the most recent sync, if it failed.
+	LastError string `json:"last_error,omitempty"`
+	// StalenessSeconds is the time since the most recent successful sync.
+	StalenessSeconds float64 `json:"staleness_seconds,omitempty"`
+	// MaxStalenessSeconds is the configured staleness threshold.
+	MaxStalenessSeconds float64 `json:"max_staleness_seconds,omitempty"`
+}
+
+// HealthHandler reports the health of consumers and cron jobs. It
+// implements http.Handler and can be mounted as a readiness probe,
+// responding with 503 Service Unavailable if any of the registered feeds
+// has never been synced successfully or is stale.
+type HealthHandler struct {
+	mu     sync.RWMutex
+	checks map[string]healthCheck
+}
+
+type healthCheck struct {
+	state func() healthState
+	opt   HealthOptions
+}
+
+type healthState struct {
+	version     int64
+	lastSuccess time.Time
+	lastErr     error
+	untracked   bool // sync times are unknown
+}
+
+// NewHealthHandler inits a new health handler.
+func NewHealthHandler() *HealthHandler {
+	return &HealthHandler{checks: make(map[string]healthCheck)}
+}
+
+// AddConsumer registers a consumer under a name. Custom Consumer
+// implementations don't track their sync times, their freshness is
+// unknown. They are considered healthy as soon as they report a non-zero
+// version, unless a MaxStaleness is configured.
+func (h *HealthHandler) AddConsumer(name string, csm Consumer, opt *HealthOptions) {
+	h.add(name, opt, func() healthState {
+		if c, ok := csm.(*consumer); ok {
+			return c.health()
+		}
+		return healthState{version: csm.Version(), untracked: true}
+	})
+}
+
+// AddCronJob registers a cron job under a name.
+func (h *HealthHandler) AddCronJob(name string, job *CronJob, opt *HealthOptions) {
+	h.add(name, opt, job.health)
+}
+
+// Remove unregisters a feed.
+func (h *HealthHandler) Remove(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.checks, name)
+}
+
+// Report returns the current health of all registered feeds.
+func (h *HealthHandler) Report() *HealthReport {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	now := time.Now()
+	report := &HealthReport{Healthy: true, Feeds: make(map[string]*FeedHealth, len(h.checks))}
+	for name, check := range h.checks {
+		fh := check.report(now)
+		report.Feeds[name] = fh
+		report.Healthy = report.Healthy && fh.Healthy
+	}
+	return report
+}
+
+// ServeHTTP implements http.Handler.
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	report := h.Report()
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if !report.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+func (h *HealthHandler) add(name string, opt *HealthOptions, state func() healthState) {
+	var o HealthOptions
+	if opt != nil {
+		o = *opt
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks[name] = healthCheck{state: state, opt: o}
+}
+
+func (c healthCheck) report(now time.Time) *FeedHealth {
+	state := c.state()
+	fh := &FeedHealth{
+		Healthy:             true,
+		Version:             state.version,
+		MaxStalenessSeconds: c.opt.MaxStaleness.Seconds(),
+	}
+	if state.lastErr != nil {
+		fh.LastError = state.lastErr.Error()
+	}
+
+	if state.untracked && state.version != 0 {
+		if c.opt.MaxStaleness > 0 {
+			fh.Healthy = false
+			fh.Reason = "unknown staleness"
+		}
+		return fh
+	}
+	if state.lastSuccess.IsZero() {
+		fh.Healthy = false
+		fh.Reason = "never synced"
+		return fh
+	}
+
+	staleness := now.Sub(state.lastSuccess)
+	fh.LastSuccess = &state.lastSuccess
+	fh.StalenessSeconds = staleness.Seconds()
+	if c.opt.MaxStaleness > 0 && staleness > c.opt.MaxStaleness {
+		fh.Healthy = false
+		fh.Reason = "stale"
+	}
+	return fh
+}
+
+func (c *consumer) health() healthState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return healthState{
+		version:     c.Version(),
+		lastSuccess: c.lastSuccess,
+		lastErr:     c.lastErr,
+	}
+}
+
+func (j *CronJob) health() healthState {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	var state healthState
+	if j.last != nil {
+		state.lastErr = j.last.Err
+	}
+	// skipped runs only count once data has been loaded
+	if j.lastSuccess != nil && j.isReady() {
+		state.lastSuccess = j.lastSuccess.Finished
+		if status := j.lastSuccess.Status; status != nil {
+			state.version = max(status.LocalVersion, status.RemoteVersion)
+		}
+	}
+	return state
+}
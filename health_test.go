@@ -0,0 +1,184 @@
+package feedx_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+)
+
+func TestHealthHandler(t *testing.T) {
+	obj := bfs.NewInMemObject("path/to/file.json")
+	defer obj.Close()
+
+	if err := writeN(obj, 2, 101); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+
+	csm := feedx.NewConsumerForRemote(obj)
+	defer csm.Close()
+
+	handler := feedx.NewHealthHandler()
+	handler.AddConsumer("todos", csm, &feedx.HealthOptions{MaxStaleness: time.Hour})
+
+	t.Run("never synced", func(t *testing.T) {
+		code, report := testHealth(t, handler)
+		if exp := http.StatusServiceUnavailable; exp != code {
+			t.Errorf("expected %v, got %v", exp, code)
+		}
+		if fh := report.Feeds["todos"]; fh.Healthy || fh.Reason != "never synced" || fh.LastSuccess != nil {
+			t.Errorf("unexpected health %+v", fh)
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		exp := errors.New("failed!")
+		if _, err := csm.Consume(t.Context(), nil, func(r *feedx.Reader) error { return exp }); err != exp {
+			t.Fatalf("expected %v, got %v", exp, err)
+		}
+
+		code, report := testHealth(t, handler)
+		if exp := http.StatusServiceUnavailable; exp != code {
+			t.Errorf("expected %v, got %v", exp, code)
+		}
+		if fh := report.Feeds["todos"]; fh.Healthy || fh.LastError != "failed!" {
+			t.Errorf("unexpected health %+v", fh)
+		}
+	})
+
+	t.Run("healthy", func(t *testing.T) {
+		if _, err := csm.Consume(t.Context(), nil, func(r *feedx.Reader) error { return nil }); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		code, report := testHealth(t, handler)
+		if exp := http.StatusOK; exp != code {
+			t.Errorf("expected %v, got %v", exp, code)
+		}
+		if !report.Healthy {
+			t.Errorf("expected report to be healthy, got %+v", report)
+		}
+		if fh := report.Feeds["todos"]; !fh.Healthy || fh.Version != 101 || fh.LastSuccess == nil || fh.LastError != "" || fh.MaxStalenessSeconds != 3600 {
+			t.Errorf("unexpected health %+v", fh)
+		}
+	})
+
+	t.Run("stale", func(t *testing.T) {
+		handler.AddConsumer("stale", csm, &feedx.HealthOptions{MaxStaleness: time.Nanosecond})
+		defer handler.Remove("stale")
+
+		time.Sleep(time.Millisecond)
+		code, report := testHealth(t, handler)
+		if exp := http.StatusServiceUnavailable; exp != code {
+			t.Errorf("expected %v, got %v", exp, code)
+		}
+		if fh := report.Feeds["stale"]; fh.Healthy || fh.Reason != "stale" || fh.StalenessSeconds <= 0 {
+			t.Errorf("unexpected health %+v", fh)
+		}
+		if fh := report.Feeds["todos"]; !fh.Healthy {
+			t.Errorf("unexpected health %+v", fh)
+		}
+	})
+
+	t.Run("cron jobs", func(t *testing.T) {
+		job := feedx.NewJob().RunEvery(time.Hour, func(j *feedx.Job) (*feedx.Status, error) {
+			return j.ConsumeWith(t.Context(), csm, func(r *feedx.Reader) error { return nil })
+		})
+		defer job.Close()
+
+		handler.AddCronJob("cron", job, nil)
+		defer handler.Remove("cron")
+
+		if fh := handler.Report().Feeds["cron"]; fh.Healthy {
+			t.Errorf("unexpected health %+v", fh)
+		}
+
+		results, cancel := job.Subscribe(1)
+		defer cancel()
+
+		job.Trigger()
+		<-results
+
+		if fh := handler.Report().Feeds["cron"]; !fh.Healthy || fh.Version != 101 {
+			t.Errorf("unexpected health %+v", fh)
+		}
+	})
+
+	t.Run("skipped cron jobs", func(t *testing.T) {
+		fresh := feedx.NewConsumerForRemote(obj)
+		defer fresh.Close()
+
+		job := feedx.NewJob().
+			BeforeSync(func(int64) bool { return false }).
+			RunEvery(time.Hour, func(j *feedx.Job) (*feedx.Status, error) {
+				return j.ConsumeWith(t.Context(), fresh, func(r *feedx.Reader) error { return nil })
+			})
+		defer job.Close()
+
+		handler.AddCronJob("skipped", job, nil)
+		defer handler.Remove("skipped")
+
+		results, cancel := job.Subscribe(1)
+		defer cancel()
+
+		job.Trigger()
+		if res := <-results; res.Err != nil || res.Status == nil || !res.Status.Skipped {
+			t.Fatalf("expected skipped run, got %+v", res)
+		}
+
+		if fh := handler.Report().Feeds["skipped"]; fh.Healthy || fh.Reason != "never synced" {
+			t.Errorf("unexpected health %+v", fh)
+		}
+	})
+
+	t.Run("custom consumers", func(t *testing.T) {
+		custom := &customConsumer{}
+		handler.AddConsumer("custom", custom, nil)
+		defer handler.Remove("custom")
+		handler.AddConsumer("custom-stale", custom, &feedx.HealthOptions{MaxStaleness: time.Hour})
+		defer handler.Remove("custom-stale")
+
+		if fh := handler.Report().Feeds["custom"]; fh.Healthy || fh.Reason != "never synced" {
+			t.Errorf("unexpected health %+v", fh)
+		}
+
+		custom.version = 101
+		if fh := handler.Report().Feeds["custom"]; !fh.Healthy || fh.LastSuccess != nil || fh.Version != 101 {
+			t.Errorf("unexpected health %+v", fh)
+		}
+		if fh := handler.Report().Feeds["custom-stale"]; fh.Healthy || fh.Reason != "unknown staleness" {
+			t.Errorf("unexpected health %+v", fh)
+		}
+	})
+}
+
+type customConsumer struct{ version int64 }
+
+func (c *customConsumer) Consume(context.Context, *feedx.ReaderOptions, feedx.ConsumeFunc) (*feedx.Status, error) {
+	return &feedx.Status{}, nil
+}
+func (c *customConsumer) Version() int64 { return c.version }
+func (c *customConsumer) Close() error   { return nil }
+
+func testHealth(t *testing.T, handler http.Handler) (int, *feedx.HealthReport) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+
+	if exp, got := "application/json; charset=utf-8", rec.Header().Get("Content-Type"); exp != got {
+		t.Errorf("expected %q, got %q", exp, got)
+	}
+
+	var report feedx.HealthReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	return rec.Code, &report
+}
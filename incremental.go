@@ -3,6 +3,8 @@ package feedx
 import (
 	"context"
 	"errors"
+	"log/slog"
+	"time"
 
 	"github.com/bsm/bfs"
 )
@@ -15,6 +17,7 @@ type IncrementalProducer struct {
 	bucket    bfs.Bucket
 	object    *bfs.Object
 	ownBucket bool
+	feed      string
 }
 
 // NewIncrementalProducer inits a new incremental feed producer.
@@ -26,14 +29,18 @@ func NewIncrementalProducer(ctx context.Context, bucketURL string) (*Incremental
 
 	pcr := NewIncrementalProducerForBucket(bucket)
 	pcr.ownBucket = true
+	pcr.feed = bucketURL
 	return pcr, nil
 }
 
-// NewIncrementalProducerForRemote starts a new incremental feed producer for a bucket.
+// NewIncrementalProducerForBucket starts a new incremental feed producer for a bucket.
+// Use WriterOptions.Feed to label its syncs.
 func NewIncrementalProducerForBucket(bucket bfs.Bucket) *IncrementalProducer {
+	object := bfs.NewObjectFromBucket(bucket, "manifest.json")
 	return &IncrementalProducer{
 		bucket: bucket,
-		object: bfs.NewObjectFromBucket(bucket, "manifest.json"),
+		object: object,
+		feed:   object.Name(),
 	}
 }
 
@@ -53,13 +60,42 @@ func (p *IncrementalProducer) Close() (err error) {
 }
 
 func (p *IncrementalProducer) Produce(ctx context.Context, version int64, opt *WriterOptions, pfn IncrementalProduceFunc) (*Status, error) {
+	start := time.Now()
+	feed := opt.feed(p.feed)
+	ctx, span := startSpan(ctx, "feedx.produce", slog.String("feedx.feed", feed), slog.Int64("feedx.local_version", version))
+	logger := opt.logger().With("feed", feed, "op", SyncOpProduce)
+	status, err := p.produce(ctx, version, opt, pfn, logger)
+	status.finish(start)
+	logSync(logger, status, err)
+	endSyncSpan(span, status, err)
+
+	event := SyncEvent{Feed: feed, Op: SyncOpProduce, Duration: status.Duration}
+	if err != nil {
+		event.VersionLag = versionLag(version, status.RemoteVersion)
+	}
+	observeSync(opt.metrics(), &event, status, err)
+
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (p *IncrementalProducer) produce(ctx context.Context, version int64, opt *WriterOptions, pfn IncrementalProduceFunc, logger *slog.Logger) (*Status, error) {
 	status := Status{LocalVersion: version}
+	policy := opt.retryPolicy()
 
 	// fetch manifest from remote object
-	mft, err := loadManifest(ctx, p.object)
+	var mft *manifest
+	retries, err := policy.do(ctx, func() (err error) {
+		mft, err = loadManifest(ctx, p.object)
+		return
+	})
+	status.Retries += retries
 	if err != nil {
-		return nil, err
+		return &status, err
 	}
+	logger.Debug("loaded manifest", "local_version", version, "remote_version", mft.Version, "files", len(mft.Files))
 
 	// skip if not modified
 	remoteVersion := mft.Version
@@ -75,21 +111,40 @@ func (p *IncrementalProducer) Produce(ctx context.Context, version int64, opt *W
 	}
 	opt.Version = version
 
+	// check schema compatibility
+	schema, err := manifestSchema(opt.Schema, mft)
+	if err != nil {
+		return &status, err
+	}
+
 	// write data modified since last version
-	numWritten, err := p.writeDataFile(ctx, mft, version, remoteVersion, opt, pfn)
+	retries, err = policy.do(ctx, func() error {
+		writer, err := p.writeDataFile(ctx, mft, version, remoteVersion, opt, pfn)
+		if writer != nil {
+			status.addWriter(writer)
+		}
+		return err
+	})
+	status.Retries += retries
 	if err != nil {
-		return nil, err
+		return &status, err
 	}
+
 	// write new manifest to remote
-	if err := p.commitManifest(ctx, mft, &WriterOptions{Version: version}); err != nil {
-		return nil, err
+	mft.Schema = schema
+	retries, err = policy.do(ctx, func() error {
+		return p.commitManifest(ctx, mft, &WriterOptions{Version: version, Logger: opt.Logger})
+	})
+	status.Retries += retries
+	if err != nil {
+		return &status, err
 	}
+	status.Objects = append(status.Objects, p.object.Name())
 
-	status.NumItems = numWritten
 	return &status, nil
 }
 
-func (p *IncrementalProducer) writeDataFile(ctx context.Context, mft *manifest, version, remoteVersion int64, opt *WriterOptions, pfn IncrementalProduceFunc) (int64, error) {
+func (p *IncrementalProducer) writeDataFile(ctx context.Context, mft *manifest, version, remoteVersion int64, opt *WriterOptions, pfn IncrementalProduceFunc) (*Writer, error) {
 	fname := mft.newDataFileName(opt)
 
 	obj := bfs.NewObjectFromBucket(p.bucket, fname)
@@ -98,17 +153,17 @@ func (p *IncrementalProducer) writeDataFile(ctx context.Context, mft *manifest,
 	writer := NewWriter(ctx, obj, opt)
 	defer writer.Discard()
 
-	if err := pfn(remoteVersion)(writer); err != nil {
-		return 0, err
+	if err := traceCallback(ctx, func() error { return pfn(remoteVersion)(writer) }); err != nil {
+		return nil, permanentError{err}
 	}
 	if err := writer.Commit(); err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	mft.Files = append(mft.Files, fname)
 	mft.Version = version
 
-	return writer.NumWritten(), nil
+	return writer, nil
 }
 
 func (p *IncrementalProducer) commitManifest(ctx context.Context, mft *manifest, opt *WriterOptions) error {
@@ -0,0 +1,334 @@
+package feedx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+
+	"github.com/bsm/bfs"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+var (
+	errNotSorted        = errors.New("feedx: index is not sorted by key")
+	errIndexCompression = errors.New("feedx: indexed feeds require a compression which supports concatenated streams")
+)
+
+// IndexOptions configure writers to produce block-structured, indexed feeds.
+// Records are grouped into blocks, which are compressed independently and
+// can be read individually, see IndexedReader. Readers can still stream
+// indexed feeds, as the compression must support concatenated streams,
+// e.g. gzip and zstd. FlateCompression is rejected.
+//
+// The index is written before the feed is committed. If committing the feed
+// fails, the index may be left ahead of the feed, which is detected by
+// IndexedReader through the version.
+type IndexOptions struct {
+	// Remote is the object the index is written to on Commit. The index
+	// is encoded as JSON, the compression is auto-detected from the name.
+	// Required.
+	Remote *bfs.Object
+
+	// BlockSize is the approximate number of uncompressed bytes per block.
+	// Default: 64 KiB
+	BlockSize int
+
+	// Key extracts the key of a record. Keys of the first record of each
+	// block are stored in the index, which allows seeking by key if records
+	// are written in key order. For sorted writers, the sort key is used.
+	// Default: nil (no keys)
+	Key func(v interface{}) string
+}
+
+func (o *IndexOptions) blockSize() int64 {
+	if o.BlockSize > 0 {
+		return int64(o.BlockSize)
+	}
+	return 64 << 10
+}
+
+// Index describes the blocks of an indexed feed.
+type Index struct {
+	// Version holds the version of the feed.
+	Version int64 `json:"version"`
+	// Sorted is true if blocks have keys and records were written in key
+	// order.
+	Sorted bool `json:"sorted"`
+	// Blocks holds the blocks, in order.
+	Blocks []IndexBlock `json:"blocks"`
+}
+
+// IndexBlock describes a block of an indexed feed.
+type IndexBlock struct {
+	// Offset is the byte offset of the block within the remote.
+	Offset int64 `json:"offset"`
+	// Size is the compressed size of the block in bytes.
+	Size int64 `json:"size"`
+	// FirstRecord is the offset of the first record of the block.
+	FirstRecord int64 `json:"first_record"`
+	// NumRecords is the number of records in the block.
+	NumRecords int64 `json:"num_records"`
+	// FirstKey is the key of the first record of the block, if keys were
+	// configured.
+	FirstKey string `json:"first_key,omitempty"`
+}
+
+// LoadIndex loads an index from a remote object.
+func LoadIndex(ctx context.Context, obj *bfs.Object) (_ *Index, err error) {
+	ctx, span := startSpan(ctx, "feedx.load_index", slog.String("feedx.object", obj.Name()))
+	defer func() { endSpan(span, err) }()
+
+	r, err := NewReader(ctx, obj, &ReaderOptions{Format: JSONFormat})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	idx := new(Index)
+	if err := r.Decode(idx); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// NumRecords returns the total number of records.
+func (x *Index) NumRecords() int64 {
+	if len(x.Blocks) == 0 {
+		return 0
+	}
+	last := x.Blocks[len(x.Blocks)-1]
+	return last.FirstRecord + last.NumRecords
+}
+
+// indexBuilder tracks blocks while an indexed feed is written.
+type indexBuilder struct {
+	opt   *IndexOptions
+	index Index
+
+	blockStart int64 // uncompressed bytes at the start of the current block
+	lastKey    string
+}
+
+func newIndexBuilder(opt *IndexOptions, version int64, keyed bool) *indexBuilder {
+	return &indexBuilder{opt: opt, index: Index{Version: version, Sorted: keyed}}
+}
+
+// --------------------------------------------------------------------
+
+// IndexedReader provides random access to indexed feeds, by seeking to the
+// block which contains a record offset or key. Blocks are read using range
+// reads, if supported by the remote.
+type IndexedReader struct {
+	ctx    context.Context
+	remote *bfs.Object
+	opt    ReaderOptions
+	index  *Index
+
+	block int   // current block
+	num   int64 // records read since last seek
+
+	br bfs.Reader
+	lr *io.LimitedReader // current block
+	cr io.ReadCloser
+	fd FormatDecoder
+}
+
+// NewIndexedReader inits a new reader for an indexed feed.
+func NewIndexedReader(ctx context.Context, remote, index *bfs.Object, opt *ReaderOptions) (*IndexedReader, error) {
+	var o ReaderOptions
+	if opt != nil {
+		o = *opt
+	}
+	o.norm(remote.Name())
+
+	var idx *Index
+	if _, err := o.retryPolicy().do(ctx, func() (err error) {
+		idx, err = LoadIndex(ctx, index)
+		return
+	}); err != nil {
+		return nil, err
+	}
+
+	var version int64
+	if _, err := o.retryPolicy().do(ctx, func() (err error) {
+		version, err = fetchRemoteVersion(ctx, remote)
+		return
+	}); err != nil {
+		return nil, err
+	} else if version != idx.Version {
+		return nil, fmt.Errorf("feedx: index version %d does not match feed version %d", idx.Version, version)
+	}
+
+	return &IndexedReader{
+		ctx:    ctx,
+		remote: remote,
+		opt:    o,
+		index:  idx,
+	}, nil
+}
+
+// Index returns the index.
+func (r *IndexedReader) Index() *Index {
+	return r.index
+}
+
+// Version returns the version of the feed, as recorded in the index.
+func (r *IndexedReader) Version() int64 {
+	return r.index.Version
+}
+
+// SeekOffset positions the reader at the n-th record of the feed, starting
+// at 0. Only the block containing the record is read.
+func (r *IndexedReader) SeekOffset(n int64) error {
+	pos := sort.Search(len(r.index.Blocks), func(i int) bool { return r.index.Blocks[i].FirstRecord > n }) - 1
+	if n < 0 || pos < 0 || n >= r.index.NumRecords() {
+		return r.seekBlock(len(r.index.Blocks))
+	}
+
+	if err := r.seekBlock(pos); err != nil {
+		return err
+	}
+	for i := r.index.Blocks[pos].FirstRecord; i < n; i++ {
+		if err := r.skip(); err != nil {
+			return err
+		}
+	}
+	r.num = 0
+	return nil
+}
+
+// SeekKey positions the reader at the start of the first block which may
+// contain key, i.e. the last block which starts with a key < key, or the
+// first block if key precedes all keys. Records with equal keys may span
+// multiple blocks. Records with smaller keys must be skipped
+// by the caller. Requires a sorted index.
+func (r *IndexedReader) SeekKey(key string) error {
+	if !r.index.Sorted {
+		return errNotSorted
+	}
+
+	pos := sort.Search(len(r.index.Blocks), func(i int) bool { return r.index.Blocks[i].FirstKey >= key }) - 1
+	return r.seekBlock(max(pos, 0))
+}
+
+// Decode decodes the next formatted value from the feed. At the end of the
+// feed, Decode returns io.EOF.
+func (r *IndexedReader) Decode(v interface{}) error {
+	for {
+		if r.block >= len(r.index.Blocks) {
+			return io.EOF
+		}
+
+		if err := r.ensureOpen(); err != nil {
+			return err
+		}
+
+		err := decodeValue(r.opt.Format, r.fd, r.opt.MessageType, v)
+		if errors.Is(err, io.EOF) {
+			if err := r.closeBlock(); err != nil {
+				return err
+			}
+			// skip trailing bytes, the next block starts at the end of the limit
+			if _, err := io.Copy(io.Discard, r.lr); err != nil {
+				return err
+			}
+			r.block++
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		r.num++
+		return nil
+	}
+}
+
+// NumRead returns the number of records read since the last seek.
+func (r *IndexedReader) NumRead() int64 {
+	return r.num
+}
+
+// Close closes the reader.
+func (r *IndexedReader) Close() error {
+	err := r.closeBlock()
+	if r.br != nil {
+		if e := r.br.Close(); e != nil {
+			err = errors.Join(err, e)
+		}
+		r.br = nil
+	}
+	return err
+}
+
+func (r *IndexedReader) seekBlock(pos int) error {
+	if err := r.Close(); err != nil {
+		return err
+	}
+	r.block = pos
+	r.num = 0
+	return nil
+}
+
+// skip decodes and discards the next record.
+func (r *IndexedReader) skip() error {
+	var v interface{} = new(interface{})
+	if r.opt.Format == ProtobufFormat {
+		v = new(emptypb.Empty)
+	}
+	return r.Decode(v)
+}
+
+// ensureOpen opens the current block. The remote is only opened once and
+// read sequentially, unless a seek occurs.
+func (r *IndexedReader) ensureOpen() error {
+	if r.fd != nil {
+		return nil
+	}
+
+	block := r.index.Blocks[r.block]
+	if r.br == nil {
+		var br bfs.Reader
+		if _, err := r.opt.retryPolicy().do(r.ctx, func() (err error) {
+			if br, err = r.remote.Open(r.ctx); err != nil {
+				return
+			}
+			if err = skipTo(br, block.Offset); err != nil {
+				_ = br.Close()
+			}
+			return
+		}); err != nil {
+			return err
+		}
+		r.br = br
+	}
+
+	r.lr = &io.LimitedReader{R: r.br, N: block.Size}
+	cr, err := r.opt.Compression.NewReader(r.lr)
+	if err != nil {
+		return err
+	}
+	r.cr = cr
+
+	fd, err := r.opt.Format.NewDecoder(cr)
+	if err != nil {
+		return err
+	}
+	r.fd = fd
+	return nil
+}
+
+func (r *IndexedReader) closeBlock() error {
+	var err error
+	if r.fd != nil {
+		err = errors.Join(err, r.fd.Close())
+		r.fd = nil
+	}
+	if r.cr != nil {
+		err = errors.Join(err, r.cr.Close())
+		r.cr = nil
+	}
+	return err
+}
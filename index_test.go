@@ -0,0 +1,285 @@
+package feedx_test
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+	"github.com/bsm/feedx/internal/testdata"
+)
+
+func TestIndexedReader(t *testing.T) {
+	keyOf := func(v interface{}) string { return v.(*testdata.MockMessage).Name }
+
+	// write writes 1000 records, named by their position in the given order.
+	write := func(t *testing.T, obj *bfs.Object, opt *feedx.WriterOptions, order func(int) int) {
+		t.Helper()
+
+		w := feedx.NewWriter(t.Context(), obj, opt)
+		defer w.Discard()
+
+		for i := 0; i < 1000; i++ {
+			if err := w.Encode(&testdata.MockMessage{Name: fmt.Sprintf("%04d", order(i)), Height: 180}); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+	}
+
+	open := func(t *testing.T, obj, index *bfs.Object) *feedx.IndexedReader {
+		t.Helper()
+
+		r, err := feedx.NewIndexedReader(t.Context(), obj, index, nil)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		t.Cleanup(func() { _ = r.Close() })
+		return r
+	}
+
+	next := func(t *testing.T, r interface{ Decode(interface{}) error }) string {
+		t.Helper()
+
+		var msg testdata.MockMessage
+		if err := r.Decode(&msg); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		return msg.Name
+	}
+
+	for _, ext := range []string{"json", "pb", "pb.gz", "cbor", "json.zst"} {
+		t.Run(ext, func(t *testing.T) {
+			obj := bfs.NewInMemObject("path/to/file." + ext)
+			defer obj.Close()
+			index := bfs.NewInMemObject("path/to/file.idx.json")
+			defer index.Close()
+
+			write(t, obj, &feedx.WriterOptions{
+				Version: 101,
+				Index:   &feedx.IndexOptions{Remote: index, BlockSize: 256, Key: keyOf},
+			}, func(i int) int { return i })
+
+			r := open(t, obj, index)
+			idx := r.Index()
+			if exp, got := int64(1000), idx.NumRecords(); exp != got {
+				t.Errorf("expected %v, got %v", exp, got)
+			}
+			if exp, got := int64(101), r.Version(); exp != got {
+				t.Errorf("expected %v, got %v", exp, got)
+			}
+			if !idx.Sorted {
+				t.Error("expected index to be sorted")
+			}
+			if n := len(idx.Blocks); n < 10 {
+				t.Fatalf("expected multiple blocks, got %d", n)
+			}
+			if exp, got := "0000", idx.Blocks[0].FirstKey; exp != got {
+				t.Errorf("expected %v, got %v", exp, got)
+			}
+
+			// seek by offset, then read across blocks
+			if err := r.SeekOffset(537); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			for i := 537; i < 1000; i++ {
+				if exp, got := fmt.Sprintf("%04d", i), next(t, r); exp != got {
+					t.Fatalf("expected %v, got %v", exp, got)
+				}
+			}
+			if err := r.Decode(new(testdata.MockMessage)); err != io.EOF {
+				t.Errorf("expected EOF, got %v", err)
+			}
+			if exp, got := int64(463), r.NumRead(); exp != got {
+				t.Errorf("expected %v, got %v", exp, got)
+			}
+
+			// seek backwards
+			if err := r.SeekOffset(0); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			if exp, got := "0000", next(t, r); exp != got {
+				t.Errorf("expected %v, got %v", exp, got)
+			}
+
+			// seek by key
+			if err := r.SeekKey("0700"); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			name := next(t, r)
+			if name > "0700" {
+				t.Fatalf("expected block to start before key, got %v", name)
+			}
+			for name < "0700" {
+				name = next(t, r)
+			}
+			if exp := "0700"; exp != name {
+				t.Errorf("expected %v, got %v", exp, name)
+			}
+
+			// seek past end
+			if err := r.SeekOffset(1000); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			if err := r.Decode(new(testdata.MockMessage)); err != io.EOF {
+				t.Errorf("expected EOF, got %v", err)
+			}
+		})
+	}
+
+	t.Run("streaming", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.pb.gz")
+		defer obj.Close()
+		index := bfs.NewInMemObject("path/to/file.idx.json")
+		defer index.Close()
+
+		write(t, obj, &feedx.WriterOptions{
+			Index: &feedx.IndexOptions{Remote: index, BlockSize: 256},
+		}, func(i int) int { return i })
+
+		r, err := feedx.NewReader(t.Context(), obj, nil)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		defer r.Close()
+
+		msgs, err := readMessages(r)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp, got := 1000, len(msgs); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+
+		if err := open(t, obj, index).SeekKey("0100"); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("sorted writer", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.pb.zst")
+		defer obj.Close()
+		index := bfs.NewInMemObject("path/to/file.idx.json.gz")
+		defer index.Close()
+
+		write(t, obj, &feedx.WriterOptions{
+			SortBy: &feedx.SortOptions{Name: "name", Key: keyOf, MaxMemory: 4096, TempDir: t.TempDir()},
+			Index:  &feedx.IndexOptions{Remote: index, BlockSize: 256},
+		}, func(i int) int { return (i * 37) % 1000 })
+
+		r := open(t, obj, index)
+		if !r.Index().Sorted {
+			t.Fatal("expected index to be sorted")
+		}
+		if exp, got := int64(1000), r.Index().NumRecords(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+
+		if err := r.SeekOffset(999); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp, got := "0999", next(t, r); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+
+		if err := r.SeekKey("0500"); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if name := next(t, r); name > "0500" {
+			t.Errorf("expected block to start before key, got %v", name)
+		}
+	})
+
+	t.Run("unsorted", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.pb")
+		defer obj.Close()
+		index := bfs.NewInMemObject("path/to/file.idx.json")
+		defer index.Close()
+
+		write(t, obj, &feedx.WriterOptions{
+			Index: &feedx.IndexOptions{Remote: index, BlockSize: 256, Key: keyOf},
+		}, func(i int) int { return 999 - i })
+
+		r := open(t, obj, index)
+		if r.Index().Sorted {
+			t.Error("expected index not to be sorted")
+		}
+		if err := r.SeekKey("0100"); err == nil {
+			t.Error("expected error")
+		}
+		if err := r.SeekOffset(10); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp, got := "0989", next(t, r); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("duplicate keys", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+		index := bfs.NewInMemObject("path/to/file.idx.json")
+		defer index.Close()
+
+		write(t, obj, &feedx.WriterOptions{
+			Index: &feedx.IndexOptions{Remote: index, BlockSize: 128, Key: keyOf},
+		}, func(i int) int { return min(max(i, 400), 600) })
+
+		r := open(t, obj, index)
+		if err := r.SeekKey("0600"); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		var n int
+		for {
+			var msg testdata.MockMessage
+			if err := r.Decode(&msg); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			if msg.Name == "0600" {
+				n++
+			}
+		}
+		if exp, got := 400, n; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("rejects flate", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.pb.flate")
+		defer obj.Close()
+		index := bfs.NewInMemObject("path/to/file.idx.json")
+		defer index.Close()
+
+		w := feedx.NewWriter(t.Context(), obj, &feedx.WriterOptions{
+			Index: &feedx.IndexOptions{Remote: index},
+		})
+		defer w.Discard()
+
+		if err := w.Encode(&testdata.MockMessage{Name: "0000"}); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("version mismatch", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.pb")
+		defer obj.Close()
+		index := bfs.NewInMemObject("path/to/file.idx.json")
+		defer index.Close()
+
+		write(t, obj, &feedx.WriterOptions{
+			Version: 101,
+			Index:   &feedx.IndexOptions{Remote: index},
+		}, func(i int) int { return i })
+		write(t, obj, &feedx.WriterOptions{Version: 102}, func(i int) int { return i })
+
+		if _, err := feedx.NewIndexedReader(t.Context(), obj, index, nil); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -0,0 +1,72 @@
+// Package recordjson converts decoded feed records to and from JSON. It is
+// shared by the feedx package and the feedx command.
+package recordjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoMarshalOptions are used to marshal protobuf messages as JSON.
+var ProtoMarshalOptions = protojson.MarshalOptions{UseProtoNames: true, UseEnumNumbers: true}
+
+// Marshal marshals a decoded record as JSON.
+func Marshal(rec any) (json.RawMessage, error) {
+	switch v := rec.(type) {
+	case json.RawMessage:
+		return v, nil
+	case proto.Message:
+		return ProtoMarshalOptions.Marshal(v)
+	default:
+		return json.Marshal(v)
+	}
+}
+
+// Unmarshal unmarshals JSON into a generic value, retaining integers.
+func Unmarshal(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return Normalize(v), nil
+}
+
+// Normalize normalizes generically decoded values. It converts map[any]any
+// values, as produced by the CBOR decoder, to map[string]any and json.Number
+// values to int64 or float64.
+func Normalize(v any) any {
+	switch vv := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(vv))
+		for key, val := range vv {
+			m[fmt.Sprint(key)] = Normalize(val)
+		}
+		return m
+	case map[string]any:
+		for key, val := range vv {
+			vv[key] = Normalize(val)
+		}
+		return vv
+	case []any:
+		for i, val := range vv {
+			vv[i] = Normalize(val)
+		}
+		return vv
+	case json.Number:
+		if n, err := vv.Int64(); err == nil {
+			return n
+		}
+		f, _ := strconv.ParseFloat(vv.String(), 64)
+		return f
+	default:
+		return v
+	}
+}
@@ -2,7 +2,7 @@ package feedx
 
 import (
 	"context"
-	"sync"
+	"log/slog"
 	"time"
 )
 
@@ -23,6 +23,8 @@ type Job struct {
 	writerOpt    *WriterOptions
 	beforeHooks  []BeforeHook
 	afterHooks   []AfterHook
+	logger       *slog.Logger
+	tracer       Tracer
 }
 
 // NewJob inits a new job.
@@ -54,6 +56,20 @@ func (j *Job) WithWriterOptions(opt *WriterOptions) *Job {
 	return j
 }
 
+// WithLogger sets a logger for the job. Unless the reader or writer
+// options specify their own, it's passed on to consumers and producers.
+func (j *Job) WithLogger(logger *slog.Logger) *Job {
+	j.logger = logger
+	return j
+}
+
+// WithTracer sets a tracer for the job. Spans are created for each run
+// and all operations performed by it.
+func (j *Job) WithTracer(tracer Tracer) *Job {
+	j.tracer = tracer
+	return j
+}
+
 // WithVersionCheck sets a custom version check for producers.
 func (j *Job) WithVersionCheck(fn VersionCheck) *Job {
 	j.versionCheck = fn
@@ -85,14 +101,32 @@ func (j *Job) ProduceIncrementally(ctx context.Context, remoteURL string, pfn In
 // ProduceWith starts a producer job with an existing producer.
 func (j *Job) ProduceWith(ctx context.Context, pcr *Producer, pfn ProduceFunc) (*Status, error) {
 	return j.produce(ctx, func(ctx context.Context, version int64) (*Status, error) {
-		return pcr.Produce(ctx, version, j.writerOpt, pfn)
+		return pcr.Produce(ctx, version, j.writerOptions(), pfn)
 	})
 }
 
 // ProduceIncrementallyFrom starts an incremental producer job with an existing producer.
 func (j *Job) ProduceIncrementallyWith(ctx context.Context, pcr *IncrementalProducer, pfn IncrementalProduceFunc) (*Status, error) {
 	return j.produce(ctx, func(ctx context.Context, version int64) (*Status, error) {
-		return pcr.Produce(ctx, version, j.writerOpt, pfn)
+		return pcr.Produce(ctx, version, j.writerOptions(), pfn)
+	})
+}
+
+// ProducePartitioned starts a partitioned producer job.
+func (j *Job) ProducePartitioned(ctx context.Context, bucketURL string, numPartitions int, keyFn PartitionKeyFunc, pfn PartitionedProduceFunc) (*Status, error) {
+	pcr, err := NewPartitionedProducer(ctx, bucketURL, numPartitions, keyFn)
+	if err != nil {
+		return nil, err
+	}
+	defer pcr.Close()
+
+	return j.ProducePartitionedWith(ctx, pcr, pfn)
+}
+
+// ProducePartitionedWith starts a partitioned producer job with an existing producer.
+func (j *Job) ProducePartitionedWith(ctx context.Context, pcr *PartitionedProducer, pfn PartitionedProduceFunc) (*Status, error) {
+	return j.produce(ctx, func(ctx context.Context, version int64) (*Status, error) {
+		return pcr.Produce(ctx, version, j.writerOptions(), pfn)
 	})
 }
 
@@ -107,22 +141,66 @@ func (j *Job) Consume(ctx context.Context, remoteURL string, cfn ConsumeFunc) (*
 }
 
 // ConsumeWith starts a consumer job with an existing consumer.
-func (j *Job) ConsumeWith(ctx context.Context, csm Consumer, cfn ConsumeFunc) (*Status, error) {
+func (j *Job) ConsumeWith(ctx context.Context, csm Consumer, cfn ConsumeFunc) (status *Status, err error) {
+	ctx, span := j.startSpan(ctx, SyncOpConsume)
+	defer func() { endSyncSpan(span, status, err) }()
+
 	return j.runWithHooks(csm.Version(), func() (*Status, error) {
-		return csm.Consume(ctx, j.readerOpt, cfn)
+		return csm.Consume(ctx, j.readerOptions(), cfn)
 	})
 }
 
 // RunEvery schedules the Job to run every interval and returns a CronJob.
+// Runs are scheduled at a fixed rate, relative to the previously scheduled
+// time rather than the end of the previous run. Runs which take longer than
+// the interval are followed by the next run immediately, the schedule
+// restarts from there.
 func (j *Job) RunEvery(interval time.Duration, perform func(*Job) (*Status, error)) *CronJob {
-	return newCronJob(j, interval, perform)
+	return newCronJob(j, intervalSchedule(interval), nil, func(_ context.Context, job *Job) (*Status, error) {
+		return perform(job)
+	})
 }
 
-func (j *Job) produce(ctx context.Context, fn func(context.Context, int64) (*Status, error)) (*Status, error) {
+// RunEveryWithOptions schedules the Job to run every interval with custom
+// options and returns a CronJob. The context passed to perform is
+// cancelled when the CronJob is closed or the configured timeout is
+// exceeded.
+func (j *Job) RunEveryWithOptions(interval time.Duration, opt *CronOptions, perform CronFunc) *CronJob {
+	return newCronJob(j, intervalSchedule(interval), opt, perform)
+}
+
+// RunOn schedules the Job to run at times matching a cron expression and
+// returns a CronJob. It accepts standard expressions with five fields
+// (minute, hour, day of month, month, day of week) or six fields, with
+// an additional leading seconds field. Expressions may be prefixed with
+// a time zone, e.g. "CRON_TZ=Europe/Berlin 5 * * * MON-FRI".
+func (j *Job) RunOn(schedule string, perform func(*Job) (*Status, error)) (*CronJob, error) {
+	return j.RunOnWithOptions(schedule, nil, func(_ context.Context, job *Job) (*Status, error) {
+		return perform(job)
+	})
+}
+
+// RunOnWithOptions schedules the Job to run at times matching a cron
+// expression with custom options and returns a CronJob.
+func (j *Job) RunOnWithOptions(schedule string, opt *CronOptions, perform CronFunc) (*CronJob, error) {
+	sched, err := parseCronSchedule(schedule)
+	if err != nil {
+		return nil, err
+	}
+	return newCronJob(j, sched, opt, perform), nil
+}
+
+func (j *Job) produce(ctx context.Context, fn func(context.Context, int64) (*Status, error)) (status *Status, err error) {
+	ctx, span := j.startSpan(ctx, SyncOpProduce)
+	defer func() { endSyncSpan(span, status, err) }()
+
 	var version int64
 	if j.versionCheck != nil {
-		latest, err := j.versionCheck(ctx)
+		vctx, vspan := startSpan(ctx, "feedx.version_check")
+		latest, err := j.versionCheck(vctx)
+		endSpan(vspan, err)
 		if err != nil {
+			loggerOrDiscard(j.logger).Error("version check failed", "error", err)
 			return nil, err
 		}
 		version = latest
@@ -136,6 +214,7 @@ func (j *Job) produce(ctx context.Context, fn func(context.Context, int64) (*Sta
 func (j *Job) runWithHooks(localVersion int64, fn func() (*Status, error)) (*Status, error) {
 	for _, hook := range j.beforeHooks {
 		if !hook(localVersion) {
+			loggerOrDiscard(j.logger).Debug("sync aborted by before hook", "local_version", localVersion)
 			return &Status{Skipped: true, LocalVersion: localVersion}, nil
 		}
 	}
@@ -147,45 +226,35 @@ func (j *Job) runWithHooks(localVersion int64, fn func() (*Status, error)) (*Sta
 	return status, err
 }
 
-// ----------------------------------------------------------------------------
-
-// CronJob runs in regular intervals until it's stopped.
-type CronJob struct {
-	cancel   context.CancelFunc
-	job      *Job
-	interval time.Duration
-	perform  func(*Job) (*Status, error)
-	wait     sync.WaitGroup
+func (j *Job) startSpan(ctx context.Context, op SyncOp) (context.Context, Span) {
+	if j.tracer != nil {
+		ctx = ContextWithTracer(ctx, j.tracer)
+	}
+	return startSpan(ctx, "feedx.job", slog.String("feedx.op", string(op)))
 }
 
-func newCronJob(job *Job, interval time.Duration, perform func(*Job) (*Status, error)) *CronJob {
-	ctx, cancel := context.WithCancel(context.Background())
-	cron := &CronJob{cancel: cancel, job: job, interval: interval, perform: perform}
-	go cron.loop(ctx)
-	return cron
-}
+func (j *Job) readerOptions() *ReaderOptions {
+	if j.logger == nil || (j.readerOpt != nil && j.readerOpt.Logger != nil) {
+		return j.readerOpt
+	}
 
-// Close stops the job and waits until it is complete.
-func (j *CronJob) Close() error {
-	j.cancel()
-	j.wait.Wait()
-	return nil
+	var o ReaderOptions
+	if j.readerOpt != nil {
+		o = *j.readerOpt
+	}
+	o.Logger = j.logger
+	return &o
 }
 
-func (j *CronJob) loop(ctx context.Context) {
-	j.wait.Add(1)
-	defer j.wait.Done()
-
-	ticker := time.NewTicker(j.interval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-		}
+func (j *Job) writerOptions() *WriterOptions {
+	if j.logger == nil || (j.writerOpt != nil && j.writerOpt.Logger != nil) {
+		return j.writerOpt
+	}
 
-		_, _ = j.perform(j.job)
+	var o WriterOptions
+	if j.writerOpt != nil {
+		o = *j.writerOpt
 	}
+	o.Logger = j.logger
+	return &o
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -51,7 +52,7 @@ func TestJob(t *testing.T) {
 		if err != nil {
 			t.Fatal("unexpected error", err)
 		}
-		if exp := (&feedx.Status{LocalVersion: 101}); !reflect.DeepEqual(exp, status) {
+		if exp := (&feedx.Status{LocalVersion: 101}); !reflect.DeepEqual(exp, stripTimings(t, status)) {
 			t.Errorf("expected %v, got %v", exp, status)
 		}
 		if exp, got := int32(1), numCycles.Load(); exp != got {
@@ -158,7 +159,7 @@ func TestJob(t *testing.T) {
 			t.Fatal("unexpected error", err)
 		}
 
-		if exp := (&feedx.Status{}); !reflect.DeepEqual(exp, status) {
+		if exp := (&feedx.Status{}); !reflect.DeepEqual(exp, stripTimings(t, status)) {
 			t.Errorf("expected %v, got %v", exp, status)
 		}
 	})
@@ -233,3 +234,158 @@ func TestJob(t *testing.T) {
 		}
 	})
 }
+
+func TestCronJob(t *testing.T) {
+	t.Run("runs on start", func(t *testing.T) {
+		clock := newFakeClock()
+		numRuns := new(atomic.Int32)
+		cron := feedx.NewJob().RunEveryWithOptions(time.Hour, clock.options(&feedx.CronOptions{
+			RunOnStart: true,
+		}), func(_ context.Context, _ *feedx.Job) (*feedx.Status, error) {
+			numRuns.Add(1)
+			return &feedx.Status{}, nil
+		})
+		defer cron.Close()
+
+		if exp, got := time.Duration(0), clock.wait(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := time.Hour, <-clock.delays; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := int32(1), numRuns.Load(); exp != got {
+			t.Errorf("expected %d, got %d", exp, got)
+		}
+	})
+
+	t.Run("backs off after failures", func(t *testing.T) {
+		clock := newFakeClock()
+		numRuns := new(atomic.Int32)
+		cron := feedx.NewJob().RunEveryWithOptions(time.Millisecond, clock.options(&feedx.CronOptions{
+			RunOnStart: true,
+			Backoff:    20 * time.Millisecond,
+			MaxBackoff: time.Minute,
+		}), func(_ context.Context, _ *feedx.Job) (*feedx.Status, error) {
+			if numRuns.Add(1) > 14 {
+				return &feedx.Status{}, nil
+			}
+			return nil, fmt.Errorf("failed!")
+		})
+		defer cron.Close()
+
+		// the first run is immediate, followed by doubling delays
+		var delays []time.Duration
+		for range 16 {
+			delays = append(delays, clock.wait())
+		}
+		if exp := []time.Duration{
+			0,
+			20 * time.Millisecond,
+			40 * time.Millisecond,
+			80 * time.Millisecond,
+			160 * time.Millisecond,
+			320 * time.Millisecond,
+			640 * time.Millisecond,
+			1280 * time.Millisecond,
+			2560 * time.Millisecond,
+			5120 * time.Millisecond,
+			10240 * time.Millisecond,
+			20480 * time.Millisecond,
+			40960 * time.Millisecond,
+			time.Minute,
+			time.Minute,
+			0, // missed run after success
+		}; !reflect.DeepEqual(exp, delays) {
+			t.Errorf("expected %v, got %v", exp, delays)
+		}
+		if exp, got := int32(15), numRuns.Load(); exp != got {
+			t.Errorf("expected %d, got %d", exp, got)
+		}
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		errs := make(chan error, 1)
+		cron := feedx.NewJob().RunEveryWithOptions(time.Hour, &feedx.CronOptions{
+			RunOnStart: true,
+			Timeout:    time.Millisecond,
+		}, func(ctx context.Context, _ *feedx.Job) (*feedx.Status, error) {
+			<-ctx.Done()
+			errs <- ctx.Err()
+			return nil, ctx.Err()
+		})
+		defer cron.Close()
+
+		select {
+		case err := <-errs:
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+			}
+		case <-time.After(time.Second):
+			t.Error("expected run to time out")
+		}
+	})
+
+	t.Run("cancels on close", func(t *testing.T) {
+		started := make(chan struct{})
+		cron := feedx.NewJob().RunEveryWithOptions(time.Hour, &feedx.CronOptions{
+			RunOnStart: true,
+		}, func(ctx context.Context, _ *feedx.Job) (*feedx.Status, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+		<-started
+		if err := cron.Close(); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+	})
+}
+
+// fakeClock reports requested delays and fires timers on demand, advancing
+// the time by the requested delay.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	delays chan time.Duration
+	timers chan chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		now:    time.Date(2024, 2, 28, 13, 7, 30, 0, time.UTC),
+		delays: make(chan time.Duration, 1),
+		timers: make(chan chan time.Time, 1),
+	}
+}
+
+func (c *fakeClock) options(opt *feedx.CronOptions) *feedx.CronOptions {
+	return feedx.WithCronClock(opt, c.Now, c.After)
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.delays <- d
+	c.timers <- ch
+	return ch
+}
+
+// wait waits for the next timer, fires it and returns its delay.
+func (c *fakeClock) wait() time.Duration {
+	d, ch := <-c.delays, <-c.timers
+
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+	c.mu.Unlock()
+
+	ch <- now
+	return d
+}
@@ -0,0 +1,41 @@
+package feedx
+
+import (
+	"log/slog"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+func loggerOrDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return discardLogger
+	}
+	return logger
+}
+
+// logSync logs the outcome of a sync attempt.
+func logSync(logger *slog.Logger, status *Status, err error) {
+	attrs := []any{
+		"local_version", status.LocalVersion,
+		"remote_version", status.RemoteVersion,
+		"duration", status.Duration,
+	}
+
+	switch {
+	case err != nil:
+		logger.Error("sync failed", append(attrs, "retries", status.Retries, "error", err)...)
+	case status.Skipped:
+		logger.Debug("sync skipped, not modified", attrs...)
+	default:
+		attrs = append(attrs,
+			"items", status.NumItems,
+			"bytes", status.NumBytes,
+			"files", status.NumFiles,
+			"retries", status.Retries,
+		)
+		if status.NumInvalidItems != 0 {
+			attrs = append(attrs, "invalid_items", status.NumInvalidItems)
+		}
+		logger.Info("sync completed", attrs...)
+	}
+}
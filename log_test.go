@@ -0,0 +1,118 @@
+package feedx_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+)
+
+func TestLogger(t *testing.T) {
+	t.Run("producer", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		pcr := feedx.NewProducerForRemote(obj)
+		defer pcr.Close()
+
+		buf, logger := newTestLogger()
+		for i := 0; i < 2; i++ {
+			if _, err := pcr.Produce(t.Context(), 101, &feedx.WriterOptions{Logger: logger}, func(w *feedx.Writer) error {
+				return w.Encode(seed())
+			}); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		}
+
+		assertLogLines(t, buf, []string{
+			`level=DEBUG msg="fetched remote version" feed=path/to/file.json op=produce local_version=101 remote_version=0`,
+			`level=DEBUG msg="committed object" object=path/to/file.json version=101 items=1 bytes=37`,
+			`level=INFO msg="sync completed" feed=path/to/file.json op=produce local_version=101 remote_version=0 items=1 bytes=37 files=1 retries=0`,
+			`level=DEBUG msg="fetched remote version" feed=path/to/file.json op=produce local_version=101 remote_version=101`,
+			`level=DEBUG msg="sync skipped, not modified" feed=path/to/file.json op=produce local_version=101 remote_version=101`,
+		})
+	})
+
+	t.Run("incremental consumer", func(t *testing.T) {
+		buf, logger := newTestLogger()
+		csm := feedx.NewIncrementalConsumerForBucket(fixIncrementalBucket(t, 101))
+		defer csm.Close()
+
+		exp := errors.New("failed!")
+		if _, err := csm.Consume(t.Context(), &feedx.ReaderOptions{Logger: logger}, func(r *feedx.Reader) error {
+			if _, err := r.Read(make([]byte, 1)); err != nil {
+				return err
+			}
+			return exp
+		}); err != exp {
+			t.Fatalf("expected %v, got %v", exp, err)
+		}
+
+		assertLogLines(t, buf, []string{
+			`level=DEBUG msg="fetched remote version" feed=manifest.json op=consume local_version=0 remote_version=101`,
+			`level=DEBUG msg="loaded manifest" feed=manifest.json op=consume remote_version=101 files=2`,
+			`level=DEBUG msg="opened object" object=data-0-0.json`,
+			`level=ERROR msg="sync failed" feed=manifest.json op=consume local_version=0 remote_version=101 retries=0 error=failed!`,
+		})
+	})
+
+	t.Run("job", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		if err := writeN(obj, 2, 101); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		csm := feedx.NewConsumerForRemote(obj)
+		defer csm.Close()
+
+		buf, logger := newTestLogger()
+		job := feedx.NewJob().WithLogger(logger)
+		if _, err := job.ConsumeWith(t.Context(), csm, func(r *feedx.Reader) error {
+			_, err := readMessages(r)
+			return err
+		}); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		job.BeforeSync(func(_ int64) bool { return false })
+		if _, err := job.ConsumeWith(t.Context(), csm, func(r *feedx.Reader) error { return nil }); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		assertLogLines(t, buf, []string{
+			`level=DEBUG msg="fetched remote version" feed=path/to/file.json op=consume local_version=0 remote_version=101`,
+			`level=DEBUG msg="opened object" object=path/to/file.json`,
+			`level=INFO msg="sync completed" feed=path/to/file.json op=consume local_version=0 remote_version=101 items=2 bytes=74 files=1 retries=0`,
+			`level=DEBUG msg="sync aborted by before hook" local_version=101`,
+		})
+	})
+}
+
+// newTestLogger returns a logger which writes deterministic output to a buffer.
+func newTestLogger() (*bytes.Buffer, *slog.Logger) {
+	buf := new(bytes.Buffer)
+	return buf, slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "duration" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+func assertLogLines(t *testing.T, buf *bytes.Buffer, exp []string) {
+	t.Helper()
+
+	got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if strings.Join(exp, "\n") != strings.Join(got, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(exp, "\n"), strings.Join(got, "\n"))
+	}
+}
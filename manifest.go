@@ -3,14 +3,16 @@ package feedx
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"strconv"
 	"strings"
 
 	"github.com/bsm/bfs"
 )
 
-// manifest holds the current feed status.
-// the current manifest is consumed before each push and a new manifest written after each push.
+// manifest holds the current status of an incremental or partitioned feed.
+// The current manifest is consumed before each push and a new manifest written after each push.
 type manifest struct {
 	// Version holds the most recent version of the records included in Files.
 	Version int64 `json:"version"`
@@ -18,9 +20,19 @@ type manifest struct {
 	Generation int `json:"generation"`
 	// Files holds a set of data files
 	Files []string `json:"files"`
+	// Partitions holds the number of partitions, if the feed is partitioned.
+	// For partitioned feeds, Files[i] holds the data of partition i.
+	Partitions int `json:"partitions,omitempty"`
+	// Schema holds the schema of the records, if published by the producer.
+	Schema *EncodedSchema `json:"schema,omitempty"`
 }
 
-func loadManifest(ctx context.Context, obj *bfs.Object) (*manifest, error) {
+// loadManifest loads a manifest from a remote object. It returns an empty
+// manifest if the object does not exist.
+func loadManifest(ctx context.Context, obj *bfs.Object) (_ *manifest, err error) {
+	ctx, span := startSpan(ctx, "feedx.load_manifest", slog.String("feedx.object", obj.Name()))
+	defer func() { endSpan(span, err) }()
+
 	m := new(manifest)
 
 	r, err := NewReader(ctx, obj, nil)
@@ -42,7 +54,36 @@ func loadManifest(ctx context.Context, obj *bfs.Object) (*manifest, error) {
 
 func (m *manifest) newDataFileName(wopt *WriterOptions) string {
 	version := strings.ReplaceAll(strconv.FormatInt(wopt.Version, 10), ".", "")
+	return "data-" + strconv.Itoa(m.Generation) + "-" + version + dataFileExt(wopt)
+}
+
+func (m *manifest) newPartitionFileName(wopt *WriterOptions, partition int) string {
+	version := strings.ReplaceAll(strconv.FormatInt(wopt.Version, 10), ".", "")
+	return "part-" + strconv.Itoa(partition) + "-of-" + strconv.Itoa(m.Partitions) + "-" + version + dataFileExt(wopt)
+}
+
+// partitionFiles returns the files of the partitions assigned to
+// consumer shard index of count. Feeds which have not been produced yet
+// have no files.
+func (m *manifest) partitionFiles(index, count int) ([]string, error) {
+	if m.Version == 0 && len(m.Files) == 0 {
+		return nil, nil
+	} else if m.Partitions == 0 {
+		return nil, errNotPartitioned
+	} else if len(m.Files) != m.Partitions {
+		return nil, fmt.Errorf("feedx: manifest lists %d files for %d partitions", len(m.Files), m.Partitions)
+	}
+
+	var files []string
+	for p, file := range m.Files {
+		if p%count == index {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
 
+func dataFileExt(wopt *WriterOptions) string {
 	formatExt := ".json"
 	switch wopt.Format {
 	case ProtobufFormat:
@@ -61,5 +102,5 @@ func (m *manifest) newDataFileName(wopt *WriterOptions) string {
 		compressionSuffix = ".zst"
 	}
 
-	return "data-" + strconv.Itoa(m.Generation) + "-" + version + formatExt + compressionSuffix
+	return formatExt + compressionSuffix
 }
@@ -0,0 +1,119 @@
+package feedx
+
+import (
+	"expvar"
+	"sync"
+	"time"
+)
+
+// SyncOp identifies the operation of a sync.
+type SyncOp string
+
+// Supported sync operations.
+const (
+	SyncOpProduce SyncOp = "produce"
+	SyncOpConsume SyncOp = "consume"
+	SyncOpCron    SyncOp = "cron"
+)
+
+// SyncEvent describes a completed sync attempt.
+type SyncEvent struct {
+	// Feed identifies the feed, e.g. by its URL.
+	Feed string
+	// Op is the sync operation.
+	Op SyncOp
+	// Duration is the total duration of the attempt.
+	Duration time.Duration
+	// Skipped is true if the sync was skipped, because there were no new changes.
+	Skipped bool
+	// NumItems is the number of items read or written.
+	NumItems int64
+	// NumBytes is the number of uncompressed bytes read or written.
+	NumBytes int64
+	// NumCompressedBytes is the number of compressed bytes read or written.
+	NumCompressedBytes int64
+	// VersionLag is the difference between the remote and the local
+	// version after the attempt. Consumers report how far they are behind
+	// the remote feed, producers how far the remote feed is behind.
+	VersionLag int64
+	// Err is the error of a failed attempt.
+	Err error
+}
+
+// Metrics instances record sync metrics.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// ObserveSync records a completed sync attempt.
+	ObserveSync(*SyncEvent)
+}
+
+func observeSync(m Metrics, ev *SyncEvent, status *Status, err error) {
+	if m == nil {
+		return
+	}
+
+	ev.Err = err
+	if status != nil {
+		ev.Skipped = status.Skipped
+		ev.NumItems = status.NumItems
+		ev.NumBytes = status.NumBytes
+		ev.NumCompressedBytes = status.NumCompressedBytes
+	}
+	m.ObserveSync(ev)
+}
+
+// versionLag returns the number of versions behind lags behind ahead.
+func versionLag(ahead, behind int64) int64 {
+	if ahead > behind {
+		return ahead - behind
+	}
+	return 0
+}
+
+// ----------------------------------------------------------------------------
+
+// ExpvarMetrics publishes sync metrics via expvar. Metrics are grouped
+// by feed and operation, e.g. "feeds.s3://bucket/feed.json.consume".
+type ExpvarMetrics struct {
+	root *expvar.Map
+	mu   sync.Mutex
+}
+
+// NewExpvarMetrics publishes sync metrics under the given expvar name.
+// Like expvar.Publish, it panics if the name is already registered.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	return newExpvarMetrics(expvar.NewMap(name))
+}
+
+func newExpvarMetrics(root *expvar.Map) *ExpvarMetrics {
+	return &ExpvarMetrics{root: root}
+}
+
+// ObserveSync implements Metrics.
+func (m *ExpvarMetrics) ObserveSync(ev *SyncEvent) {
+	key := ev.Feed + "." + string(ev.Op)
+
+	m.mu.Lock()
+	vars, ok := m.root.Get(key).(*expvar.Map)
+	if !ok {
+		vars = new(expvar.Map).Init()
+		m.root.Set(key, vars)
+	}
+	m.mu.Unlock()
+
+	vars.Add("syncs", 1)
+	vars.AddFloat("duration_seconds", ev.Duration.Seconds())
+	vars.Add("items", ev.NumItems)
+	vars.Add("bytes", ev.NumBytes)
+	vars.Add("compressed_bytes", ev.NumCompressedBytes)
+
+	lag := new(expvar.Int)
+	lag.Set(ev.VersionLag)
+	vars.Set("version_lag", lag)
+
+	if ev.Err != nil {
+		vars.Add("errors", 1)
+	} else if ev.Skipped {
+		vars.Add("skips", 1)
+	}
+}
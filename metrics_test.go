@@ -0,0 +1,206 @@
+package feedx_test
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"sync"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Run("producer", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		pcr := feedx.NewProducerForRemote(obj)
+		defer pcr.Close()
+
+		metrics := new(mockMetrics)
+		opt := &feedx.WriterOptions{Metrics: metrics}
+		for _, version := range []int64{101, 101} {
+			if _, err := pcr.Produce(t.Context(), version, opt, func(w *feedx.Writer) error {
+				return w.Encode(seed())
+			}); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		}
+
+		if exp, got := 2, len(metrics.events); exp != got {
+			t.Fatalf("expected %v, got %v", exp, got)
+		}
+		if ev := metrics.events[0]; ev.Feed != "path/to/file.json" || ev.Op != feedx.SyncOpProduce || ev.Skipped || ev.NumItems != 1 {
+			t.Errorf("unexpected event %#v", ev)
+		} else if ev.NumBytes == 0 || ev.NumBytes != ev.NumCompressedBytes {
+			t.Errorf("unexpected byte counts %v/%v", ev.NumBytes, ev.NumCompressedBytes)
+		}
+		if ev := metrics.events[1]; !ev.Skipped || ev.NumItems != 0 || ev.Err != nil {
+			t.Errorf("unexpected event %#v", ev)
+		}
+	})
+
+	t.Run("producer errors", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		pcr := feedx.NewProducerForRemote(obj)
+		defer pcr.Close()
+
+		metrics := new(mockMetrics)
+		exp := errors.New("failed!")
+		if _, err := pcr.Produce(t.Context(), 101, &feedx.WriterOptions{Metrics: metrics}, func(w *feedx.Writer) error {
+			return exp
+		}); err != exp {
+			t.Fatalf("expected %v, got %v", exp, err)
+		}
+
+		if exp, got := 1, len(metrics.events); exp != got {
+			t.Fatalf("expected %v, got %v", exp, got)
+		}
+		if ev := metrics.events[0]; ev.Err != exp || ev.VersionLag != 101 {
+			t.Errorf("unexpected event %#v", ev)
+		}
+	})
+
+	t.Run("consumer", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.jsonz")
+		defer obj.Close()
+
+		if err := writeN(obj, 10, 101); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		csm := feedx.NewConsumerForRemote(obj)
+		defer csm.Close()
+
+		metrics := new(mockMetrics)
+		opt := &feedx.ReaderOptions{Metrics: metrics}
+		for i := 0; i < 2; i++ {
+			if _, err := csm.Consume(t.Context(), opt, func(r *feedx.Reader) error {
+				_, err := readMessages(r)
+				return err
+			}); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		}
+
+		if exp, got := 2, len(metrics.events); exp != got {
+			t.Fatalf("expected %v, got %v", exp, got)
+		}
+		if ev := metrics.events[0]; ev.Feed != "path/to/file.jsonz" || ev.Op != feedx.SyncOpConsume || ev.Skipped || ev.NumItems != 10 || ev.VersionLag != 0 {
+			t.Errorf("unexpected event %#v", ev)
+		} else if ev.NumCompressedBytes == 0 || ev.NumBytes <= ev.NumCompressedBytes {
+			t.Errorf("unexpected byte counts %v/%v", ev.NumBytes, ev.NumCompressedBytes)
+		}
+		if ev := metrics.events[1]; !ev.Skipped {
+			t.Errorf("unexpected event %#v", ev)
+		}
+	})
+
+	t.Run("consumer errors", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		if err := writeN(obj, 10, 101); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		csm := feedx.NewConsumerForRemote(obj)
+		defer csm.Close()
+
+		metrics := new(mockMetrics)
+		exp := errors.New("failed!")
+		if _, err := csm.Consume(t.Context(), &feedx.ReaderOptions{Metrics: metrics}, func(r *feedx.Reader) error {
+			return exp
+		}); err != exp {
+			t.Fatalf("expected %v, got %v", exp, err)
+		}
+
+		if exp, got := 1, len(metrics.events); exp != got {
+			t.Fatalf("expected %v, got %v", exp, got)
+		}
+		if ev := metrics.events[0]; ev.Err != exp || ev.VersionLag != 101 {
+			t.Errorf("unexpected event %#v", ev)
+		}
+	})
+
+	t.Run("feed labels", func(t *testing.T) {
+		bucket := bfs.NewInMem()
+		defer bucket.Close()
+
+		pcr := feedx.NewIncrementalProducerForBucket(bucket)
+		defer pcr.Close()
+
+		csm := feedx.NewIncrementalConsumerForBucket(bucket)
+		defer csm.Close()
+
+		metrics := new(mockMetrics)
+		if _, err := pcr.Produce(t.Context(), 101, &feedx.WriterOptions{Metrics: metrics, Feed: "orders"}, func(_ int64) feedx.ProduceFunc {
+			return func(w *feedx.Writer) error { return w.Encode(seed()) }
+		}); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if _, err := csm.Consume(t.Context(), &feedx.ReaderOptions{Metrics: metrics, Feed: "orders"}, func(r *feedx.Reader) error {
+			_, err := readMessages(r)
+			return err
+		}); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if _, err := csm.Consume(t.Context(), &feedx.ReaderOptions{Metrics: metrics}, func(r *feedx.Reader) error {
+			return nil
+		}); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		if exp, got := 3, len(metrics.events); exp != got {
+			t.Fatalf("expected %v, got %v", exp, got)
+		}
+		for i, exp := range []string{"orders", "orders", "manifest.json"} {
+			if got := metrics.events[i].Feed; exp != got {
+				t.Errorf("[%d] expected %v, got %v", i, exp, got)
+			}
+		}
+	})
+}
+
+func TestExpvarMetrics(t *testing.T) {
+	root := new(expvar.Map).Init()
+	metrics := feedx.NewExpvarMetricsMap(root)
+	metrics.ObserveSync(&feedx.SyncEvent{Feed: "feed.json", Op: feedx.SyncOpConsume, NumItems: 10, NumBytes: 100, VersionLag: 3})
+	metrics.ObserveSync(&feedx.SyncEvent{Feed: "feed.json", Op: feedx.SyncOpConsume, Skipped: true})
+	metrics.ObserveSync(&feedx.SyncEvent{Feed: "feed.json", Op: feedx.SyncOpConsume, Err: errors.New("failed!")})
+
+	var vars map[string]map[string]float64
+	if err := json.Unmarshal([]byte(root.String()), &vars); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+
+	got := vars["feed.json.consume"]
+	for key, exp := range map[string]float64{
+		"syncs":       3,
+		"skips":       1,
+		"errors":      1,
+		"items":       10,
+		"bytes":       100,
+		"version_lag": 0,
+	} {
+		if got[key] != exp {
+			t.Errorf("expected %s to be %v, got %v", key, exp, got[key])
+		}
+	}
+}
+
+type mockMetrics struct {
+	mu     sync.Mutex
+	events []feedx.SyncEvent
+}
+
+func (m *mockMetrics) ObserveSync(ev *feedx.SyncEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events = append(m.events, *ev)
+}
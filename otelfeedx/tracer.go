@@ -0,0 +1,80 @@
+// Package otelfeedx adapts OpenTelemetry tracers for use with feedx.
+package otelfeedx
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bsm/feedx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewTracer wraps an OpenTelemetry tracer.
+//
+// Example:
+//
+//	tracer := otelfeedx.NewTracer(otel.Tracer("github.com/bsm/feedx"))
+//	job := feedx.NewJob().WithTracer(tracer)
+func NewTracer(tracer trace.Tracer) feedx.Tracer {
+	return otelTracer{tracer: tracer}
+}
+
+type otelTracer struct {
+	tracer trace.Tracer
+}
+
+// Start implements feedx.Tracer.
+func (t otelTracer) Start(ctx context.Context, name string) (context.Context, feedx.Span) {
+	ctx, span := t.tracer.Start(ctx, name)
+	return ctx, otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+// SetAttributes implements feedx.Span.
+func (s otelSpan) SetAttributes(attrs ...slog.Attr) {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		kvs = append(kvs, convertAttr(attr))
+	}
+	s.span.SetAttributes(kvs...)
+}
+
+// RecordError implements feedx.Span.
+func (s otelSpan) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End implements feedx.Span.
+func (s otelSpan) End() {
+	s.span.End()
+}
+
+func convertAttr(attr slog.Attr) attribute.KeyValue {
+	val := attr.Value.Resolve()
+	switch val.Kind() {
+	case slog.KindString:
+		return attribute.String(attr.Key, val.String())
+	case slog.KindInt64:
+		return attribute.Int64(attr.Key, val.Int64())
+	case slog.KindUint64:
+		return attribute.Int64(attr.Key, int64(val.Uint64()))
+	case slog.KindFloat64:
+		return attribute.Float64(attr.Key, val.Float64())
+	case slog.KindBool:
+		return attribute.Bool(attr.Key, val.Bool())
+	case slog.KindDuration:
+		return attribute.Int64(attr.Key, val.Duration().Milliseconds())
+	case slog.KindTime:
+		return attribute.String(attr.Key, val.Time().Format(time.RFC3339Nano))
+	default:
+		return attribute.String(attr.Key, fmt.Sprint(val.Any()))
+	}
+}
@@ -0,0 +1,67 @@
+package otelfeedx_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+	"github.com/bsm/feedx/otelfeedx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer provider.Shutdown(t.Context())
+
+	obj := bfs.NewInMemObject("path/to/file.json")
+	defer obj.Close()
+
+	pcr := feedx.NewProducerForRemote(obj)
+	defer pcr.Close()
+
+	exp := errors.New("failed!")
+	ctx := feedx.ContextWithTracer(t.Context(), otelfeedx.NewTracer(provider.Tracer("test")))
+	if _, err := pcr.Produce(ctx, 101, nil, func(w *feedx.Writer) error {
+		return exp
+	}); err != exp {
+		t.Fatalf("expected %v, got %v", exp, err)
+	}
+
+	spans := recorder.Ended()
+	if exp, got := 3, len(spans); exp != got {
+		t.Fatalf("expected %v, got %v", exp, got)
+	}
+
+	root := spans[len(spans)-1]
+	if exp, got := "feedx.produce", root.Name(); exp != got {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+	if exp, got := codes.Error, root.Status().Code; exp != got {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range root.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if exp, got := "path/to/file.json", attrs["feedx.feed"].AsString(); exp != got {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+	if exp, got := int64(101), attrs["feedx.local_version"].AsInt64(); exp != got {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+	if exp, got := false, attrs["feedx.skipped"].AsBool(); exp != got {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+
+	for _, span := range spans[:len(spans)-1] {
+		if exp, got := root.SpanContext().SpanID(), span.Parent().SpanID(); exp != got {
+			t.Errorf("expected %s to be a child of %s", span.Name(), root.Name())
+		}
+	}
+}
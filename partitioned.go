@@ -0,0 +1,287 @@
+package feedx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"time"
+
+	"github.com/bsm/bfs"
+)
+
+var errNotPartitioned = errors.New("feedx: feed is not partitioned")
+
+// PartitionKeyFunc extracts the partition key from a value.
+type PartitionKeyFunc func(v interface{}) string
+
+// PartitionedProduceFunc is a callback which is run by the partitioned producer on every iteration.
+type PartitionedProduceFunc func(*PartitionedWriter) error
+
+// PartitionedWriter encodes values into a fixed number of partitions,
+// routing each value by the hash of its partition key.
+type PartitionedWriter struct {
+	writers []*Writer
+	keyFn   PartitionKeyFunc
+}
+
+func newPartitionedWriter(ctx context.Context, remotes []*bfs.Object, keyFn PartitionKeyFunc, opt *WriterOptions) *PartitionedWriter {
+	writers := make([]*Writer, 0, len(remotes))
+	for _, remote := range remotes {
+		writers = append(writers, NewWriter(ctx, remote, opt))
+	}
+	return &PartitionedWriter{writers: writers, keyFn: keyFn}
+}
+
+// Encode appends a value to the partition matching its key.
+func (w *PartitionedWriter) Encode(v interface{}) error {
+	return w.EncodeKey(w.keyFn(v), v)
+}
+
+// EncodeKey appends a value to the partition matching the given key.
+func (w *PartitionedWriter) EncodeKey(key string, v interface{}) error {
+	return w.writers[partitionOf(key, len(w.writers))].Encode(v)
+}
+
+// NumPartitions returns the number of partitions.
+func (w *PartitionedWriter) NumPartitions() int {
+	return len(w.writers)
+}
+
+// NumWritten returns the number of written values across all partitions.
+func (w *PartitionedWriter) NumWritten() (sum int64) {
+	for _, pw := range w.writers {
+		sum += pw.NumWritten()
+	}
+	return
+}
+
+// NumInvalid returns the number of values which failed validation across
+// all partitions.
+func (w *PartitionedWriter) NumInvalid() (sum int64) {
+	for _, pw := range w.writers {
+		sum += pw.NumInvalid()
+	}
+	return
+}
+
+// Discard closes the writer and discards the contents of all partitions.
+func (w *PartitionedWriter) Discard() (err error) {
+	for _, pw := range w.writers {
+		if e := pw.Discard(); e != nil {
+			err = errors.Join(err, e)
+		}
+	}
+	return
+}
+
+// Commit closes the writer and persists the contents of all partitions.
+// Partitions without values are persisted as empty objects.
+func (w *PartitionedWriter) Commit() error {
+	for _, pw := range w.writers {
+		if err := pw.ensureCreated(); err != nil {
+			return err
+		}
+		if err := pw.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func partitionOf(key string, numPartitions int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(numPartitions))
+}
+
+// ----------------------------------------------------------------------------
+
+// PartitionedProducer pushes partitioned feeds to a remote bucket location.
+type PartitionedProducer struct {
+	bucket        bfs.Bucket
+	object        *bfs.Object
+	ownBucket     bool
+	numPartitions int
+	keyFn         PartitionKeyFunc
+	feed          string
+}
+
+// NewPartitionedProducer inits a new partitioned feed producer. Values are
+// distributed across numPartitions partitions by the hash of the key
+// returned by keyFn.
+func NewPartitionedProducer(ctx context.Context, bucketURL string, numPartitions int, keyFn PartitionKeyFunc) (*PartitionedProducer, error) {
+	bucket, err := bfs.Connect(ctx, bucketURL)
+	if err != nil {
+		return nil, err
+	}
+
+	pcr, err := NewPartitionedProducerForBucket(bucket, numPartitions, keyFn)
+	if err != nil {
+		_ = bucket.Close()
+		return nil, err
+	}
+	pcr.ownBucket = true
+	pcr.feed = bucketURL
+	return pcr, nil
+}
+
+// NewPartitionedProducerForBucket starts a new partitioned feed producer for a bucket.
+// Use WriterOptions.Feed to label its syncs.
+func NewPartitionedProducerForBucket(bucket bfs.Bucket, numPartitions int, keyFn PartitionKeyFunc) (*PartitionedProducer, error) {
+	if numPartitions < 1 {
+		return nil, fmt.Errorf("feedx: invalid number of partitions %d", numPartitions)
+	}
+	if keyFn == nil {
+		return nil, errors.New("feedx: partitioning requires a key function")
+	}
+
+	object := bfs.NewObjectFromBucket(bucket, "manifest.json")
+	return &PartitionedProducer{
+		bucket:        bucket,
+		object:        object,
+		numPartitions: numPartitions,
+		keyFn:         keyFn,
+		feed:          object.Name(),
+	}, nil
+}
+
+// Close stops the producer.
+func (p *PartitionedProducer) Close() (err error) {
+	if e := p.object.Close(); e != nil {
+		err = errors.Join(err, e)
+	}
+
+	if p.ownBucket && p.bucket != nil {
+		if e := p.bucket.Close(); e != nil {
+			err = errors.Join(err, e)
+		}
+		p.bucket = nil
+	}
+	return
+}
+
+// Produce writes a new version of the feed, one object per partition, followed by the manifest.
+func (p *PartitionedProducer) Produce(ctx context.Context, version int64, opt *WriterOptions, pfn PartitionedProduceFunc) (*Status, error) {
+	start := time.Now()
+	feed := opt.feed(p.feed)
+	ctx, span := startSpan(ctx, "feedx.produce", slog.String("feedx.feed", feed), slog.Int64("feedx.local_version", version))
+	logger := opt.logger().With("feed", feed, "op", SyncOpProduce)
+	status, err := p.produce(ctx, version, opt, pfn, logger)
+	status.finish(start)
+	logSync(logger, status, err)
+	endSyncSpan(span, status, err)
+
+	event := SyncEvent{Feed: feed, Op: SyncOpProduce, Duration: status.Duration}
+	if err != nil {
+		event.VersionLag = versionLag(version, status.RemoteVersion)
+	}
+	observeSync(opt.metrics(), &event, status, err)
+
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (p *PartitionedProducer) produce(ctx context.Context, version int64, opt *WriterOptions, pfn PartitionedProduceFunc, logger *slog.Logger) (*Status, error) {
+	status := Status{LocalVersion: version}
+	policy := opt.retryPolicy()
+
+	// fetch manifest from remote object
+	var mft *manifest
+	retries, err := policy.do(ctx, func() (err error) {
+		mft, err = loadManifest(ctx, p.object)
+		return
+	})
+	status.Retries += retries
+	if err != nil {
+		return &status, err
+	}
+	logger.Debug("loaded manifest", "local_version", version, "remote_version", mft.Version, "files", len(mft.Files))
+
+	// skip if not modified
+	remoteVersion := mft.Version
+	status.RemoteVersion = remoteVersion
+	if skipSync(version, remoteVersion) {
+		status.Skipped = true
+		return &status, nil
+	}
+
+	// set version for writer
+	if opt == nil {
+		opt = new(WriterOptions)
+	}
+	opt.Version = version
+
+	// check schema compatibility
+	schema, err := manifestSchema(opt.Schema, mft)
+	if err != nil {
+		return &status, err
+	}
+
+	// write partitions
+	next := &manifest{Version: version, Generation: mft.Generation, Partitions: p.numPartitions, Schema: schema}
+	retries, err = policy.do(ctx, func() error {
+		writer, err := p.writePartitions(ctx, next, opt, pfn)
+		if writer != nil {
+			for _, pw := range writer.writers {
+				status.addWriter(pw)
+			}
+		}
+		return err
+	})
+	status.Retries += retries
+	if err != nil {
+		return &status, err
+	}
+
+	// write new manifest to remote
+	retries, err = policy.do(ctx, func() error {
+		return p.commitManifest(ctx, next, &WriterOptions{Version: version, Logger: opt.Logger})
+	})
+	status.Retries += retries
+	if err != nil {
+		return &status, err
+	}
+	status.Objects = append(status.Objects, p.object.Name())
+
+	return &status, nil
+}
+
+func (p *PartitionedProducer) writePartitions(ctx context.Context, mft *manifest, opt *WriterOptions, pfn PartitionedProduceFunc) (*PartitionedWriter, error) {
+	files := make([]string, 0, p.numPartitions)
+	remotes := make([]*bfs.Object, 0, p.numPartitions)
+	for i := 0; i < p.numPartitions; i++ {
+		fname := mft.newPartitionFileName(opt, i)
+		obj := bfs.NewObjectFromBucket(p.bucket, fname)
+		defer obj.Close()
+
+		files = append(files, fname)
+		remotes = append(remotes, obj)
+	}
+
+	writer := newPartitionedWriter(ctx, remotes, p.keyFn, opt)
+	defer writer.Discard()
+
+	if err := traceCallback(ctx, func() error { return pfn(writer) }); err != nil {
+		return nil, permanentError{err}
+	}
+	if err := writer.Commit(); err != nil {
+		return nil, err
+	}
+
+	mft.Files = files
+	return writer, nil
+}
+
+func (p *PartitionedProducer) commitManifest(ctx context.Context, mft *manifest, opt *WriterOptions) error {
+	writer := NewWriter(ctx, p.object, opt)
+	defer writer.Discard()
+
+	if err := writer.Encode(mft); err != nil {
+		return err
+	}
+	return writer.Commit()
+}
@@ -0,0 +1,202 @@
+package feedx_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+	"github.com/bsm/feedx/internal/testdata"
+)
+
+func TestPartitionedProducer(t *testing.T) {
+	bucket := bfs.NewInMem()
+	defer bucket.Close()
+
+	pcr, err := feedx.NewPartitionedProducerForBucket(bucket, 4, func(v interface{}) string {
+		return v.(*testdata.MockMessage).Name
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	defer pcr.Close()
+
+	// first produce
+	testPartitionedProduce(t, pcr, 101, &feedx.Status{
+		LocalVersion: 101,
+		NumItems:     20,
+
+		NumBytes:           700,
+		NumCompressedBytes: 700,
+		NumFiles:           4,
+		Objects: []string{
+			"part-0-of-4-101.json",
+			"part-1-of-4-101.json",
+			"part-2-of-4-101.json",
+			"part-3-of-4-101.json",
+			"manifest.json",
+		},
+	})
+
+	// second produce
+	testPartitionedProduce(t, pcr, 101, &feedx.Status{Skipped: true, LocalVersion: 101, RemoteVersion: 101})
+
+	obj := bfs.NewObjectFromBucket(bucket, "manifest.json")
+	defer obj.Close()
+
+	mft, err := feedx.LoadManifest(t.Context(), obj)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	} else if exp := (&feedx.Manifest{
+		Version:    101,
+		Partitions: 4,
+		Files: []string{
+			"part-0-of-4-101.json",
+			"part-1-of-4-101.json",
+			"part-2-of-4-101.json",
+			"part-3-of-4-101.json",
+		},
+	}); !reflect.DeepEqual(exp, mft) {
+		t.Errorf("expected %#v, got %#v", exp, mft)
+	}
+
+	// all partitions must exist, even if empty
+	if exp, got := 5, len(bucket.ObjectSizes()); exp != got {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+
+	// rejects invalid options
+	if _, err := feedx.NewPartitionedProducerForBucket(bucket, 0, func(interface{}) string { return "" }); err == nil {
+		t.Error("expected error")
+	}
+	if _, err := feedx.NewPartitionedProducerForBucket(bucket, 4, nil); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestPartitionedConsumer(t *testing.T) {
+	bucket := bfs.NewInMem()
+	defer bucket.Close()
+
+	pcr, err := feedx.NewPartitionedProducerForBucket(bucket, 4, func(v interface{}) string {
+		return v.(*testdata.MockMessage).Name
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	defer pcr.Close()
+
+	testPartitionedProduce(t, pcr, 101, &feedx.Status{
+		LocalVersion: 101,
+		NumItems:     20,
+
+		NumBytes:           700,
+		NumCompressedBytes: 700,
+		NumFiles:           4,
+		Objects: []string{
+			"part-0-of-4-101.json",
+			"part-1-of-4-101.json",
+			"part-2-of-4-101.json",
+			"part-3-of-4-101.json",
+			"manifest.json",
+		},
+	})
+
+	t.Run("reads shards", func(t *testing.T) {
+		seen := make(map[string]int)
+		for i := 0; i < 2; i++ {
+			csm, err := feedx.NewPartitionedConsumerForBucket(bucket, i, 2)
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			defer csm.Close()
+
+			var msgs []*testdata.MockMessage
+			if _, err := csm.Consume(t.Context(), nil, func(r *feedx.Reader) (err error) {
+				msgs, err = readMessages(r)
+				return err
+			}); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+
+			for _, msg := range msgs {
+				if n, ok := seen[msg.Name]; ok && n != i {
+					t.Errorf("expected %q to be consumed by a single shard", msg.Name)
+				}
+				seen[msg.Name] = i
+			}
+			if exp, got := int64(101), csm.Version(); exp != got {
+				t.Errorf("expected %v, got %v", exp, got)
+			}
+		}
+		if exp, got := 20, len(seen); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("rejects invalid index", func(t *testing.T) {
+		if _, err := feedx.NewPartitionedConsumerForBucket(bucket, 2, 2); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("reads empty feeds", func(t *testing.T) {
+		empty := bfs.NewInMem()
+		defer empty.Close()
+
+		csm, err := feedx.NewPartitionedConsumerForBucket(empty, 0, 2)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		defer csm.Close()
+
+		var msgs []*testdata.MockMessage
+		status, err := csm.Consume(t.Context(), nil, func(r *feedx.Reader) (err error) {
+			msgs, err = readMessages(r)
+			return err
+		})
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp, got := 0, len(msgs); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := 0, status.NumFiles; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("rejects unpartitioned feeds", func(t *testing.T) {
+		csm, err := feedx.NewPartitionedConsumerForBucket(fixIncrementalBucket(t, 101), 0, 2)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		defer csm.Close()
+
+		if _, err := csm.Consume(t.Context(), nil, func(r *feedx.Reader) error { return nil }); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func testPartitionedProduce(t *testing.T, pcr *feedx.PartitionedProducer, version int64, exp *feedx.Status) {
+	t.Helper()
+
+	status, err := pcr.Produce(t.Context(), version, nil, func(w *feedx.PartitionedWriter) error {
+		for i := 0; i < 20; i++ {
+			msg := seed()
+			msg.Name = string(rune('A' + i))
+			if err := w.Encode(msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+
+	if status := stripTimings(t, status); !reflect.DeepEqual(exp, status) {
+		t.Errorf("expected %#v, got %#v", exp, status)
+	}
+}
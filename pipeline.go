@@ -0,0 +1,90 @@
+package feedx
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// PipelineOptions configure concurrent record processing.
+type PipelineOptions struct {
+	// Workers specifies the number of concurrent workers.
+	// Default: runtime.NumCPU()
+	Workers int
+
+	// QueueSize specifies the maximum number of decoded values which are
+	// buffered until they are picked up by a worker.
+	// Default: 2 * Workers
+	QueueSize int
+}
+
+func (o *PipelineOptions) norm() {
+	if o.Workers < 1 {
+		o.Workers = runtime.NumCPU()
+	}
+	if o.QueueSize < 1 {
+		o.QueueSize = 2 * o.Workers
+	}
+}
+
+// ConsumeConcurrently returns a ConsumeFunc which decodes values of the feed
+// sequentially and fans them out to a pool of workers, each calling fn.
+// Values are not processed in order.
+//
+// The first error, either from decoding or from fn, cancels the context
+// passed to the remaining workers and is returned once all workers have
+// stopped.
+func ConsumeConcurrently[T any](opt *PipelineOptions, fn func(context.Context, T) error) ConsumeFunc {
+	var o PipelineOptions
+	if opt != nil {
+		o = *opt
+	}
+	o.norm()
+
+	return func(r *Reader) error {
+		ctx, cancel := context.WithCancelCause(r.ctx)
+		defer cancel(nil)
+
+		queue := make(chan T, o.QueueSize)
+
+		var wg sync.WaitGroup
+		for i := 0; i < o.Workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for v := range queue {
+					if ctx.Err() != nil {
+						continue // drain queue
+					}
+					if err := fn(ctx, v); err != nil {
+						cancel(err)
+					}
+				}
+			}()
+		}
+
+		func() {
+			defer close(queue)
+
+			for v, err := range Records[T](r) {
+				if err != nil {
+					cancel(err)
+					return
+				}
+
+				select {
+				case queue <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		wg.Wait()
+
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		return nil
+	}
+}
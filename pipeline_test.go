@@ -0,0 +1,81 @@
+package feedx_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+	"github.com/bsm/feedx/internal/testdata"
+)
+
+func TestConsumeConcurrently(t *testing.T) {
+	obj := bfs.NewInMemObject("path/to/file.json")
+	defer obj.Close()
+
+	if err := writeN(obj, 100, 101); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+
+	t.Run("consumes", func(t *testing.T) {
+		csm := feedx.NewConsumerForRemote(obj)
+		defer csm.Close()
+
+		var mu sync.Mutex
+		var msgs []*testdata.MockMessage
+		status, err := csm.Consume(t.Context(), nil, feedx.ConsumeConcurrently(&feedx.PipelineOptions{Workers: 4}, func(_ context.Context, msg *testdata.MockMessage) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			msgs = append(msgs, msg)
+			return nil
+		}))
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp, got := 100, len(msgs); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := int64(100), status.NumItems; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("stops on first error", func(t *testing.T) {
+		csm := feedx.NewConsumerForRemote(obj)
+		defer csm.Close()
+
+		exp := errors.New("failed!")
+		numCalls := new(atomic.Int32)
+		_, err := csm.Consume(t.Context(), nil, feedx.ConsumeConcurrently(&feedx.PipelineOptions{Workers: 2, QueueSize: 1}, func(ctx context.Context, _ *testdata.MockMessage) error {
+			if numCalls.Add(1) == 10 {
+				return exp
+			}
+			return ctx.Err()
+		}))
+		if !errors.Is(err, exp) {
+			t.Errorf("expected %v, got %v", exp, err)
+		}
+		if max, got := int32(14), numCalls.Load(); got > max {
+			t.Errorf("expected %v to be <= %v", got, max)
+		}
+		if exp, got := int64(0), csm.Version(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("fails on decode errors", func(t *testing.T) {
+		csm := feedx.NewConsumerForRemote(obj)
+		defer csm.Close()
+
+		_, err := csm.Consume(t.Context(), nil, feedx.ConsumeConcurrently(nil, func(_ context.Context, _ int) error {
+			return nil
+		}))
+		if err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -2,6 +2,8 @@ package feedx
 
 import (
 	"context"
+	"log/slog"
+	"time"
 
 	"github.com/bsm/bfs"
 )
@@ -13,6 +15,7 @@ type ProduceFunc func(*Writer) error
 type Producer struct {
 	remote    *bfs.Object
 	ownRemote bool
+	feed      string
 }
 
 // NewProducer inits a new feed producer.
@@ -24,12 +27,13 @@ func NewProducer(ctx context.Context, remoteURL string) (*Producer, error) {
 
 	pcr := NewProducerForRemote(remote)
 	pcr.ownRemote = true
+	pcr.feed = remoteURL
 	return pcr, nil
 }
 
 // NewProducerForRemote starts a new feed producer with a remote.
 func NewProducerForRemote(remote *bfs.Object) *Producer {
-	return &Producer{remote: remote}
+	return &Producer{remote: remote, feed: remote.Name()}
 }
 
 // Close stops the producer.
@@ -43,14 +47,42 @@ func (p *Producer) Close() error {
 }
 
 func (p *Producer) Produce(ctx context.Context, version int64, opt *WriterOptions, pfn ProduceFunc) (*Status, error) {
+	start := time.Now()
+	feed := opt.feed(p.feed)
+	ctx, span := startSpan(ctx, "feedx.produce", slog.String("feedx.feed", feed), slog.Int64("feedx.local_version", version))
+	logger := opt.logger().With("feed", feed, "op", SyncOpProduce)
+	status, err := p.produce(ctx, version, opt, pfn, logger)
+	status.finish(start)
+	logSync(logger, status, err)
+	endSyncSpan(span, status, err)
+
+	event := SyncEvent{Feed: feed, Op: SyncOpProduce, Duration: status.Duration}
+	if err != nil {
+		event.VersionLag = versionLag(version, status.RemoteVersion)
+	}
+	observeSync(opt.metrics(), &event, status, err)
+
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (p *Producer) produce(ctx context.Context, version int64, opt *WriterOptions, pfn ProduceFunc, logger *slog.Logger) (*Status, error) {
 	status := Status{LocalVersion: version}
 
 	// retrieve previous remote version
-	remoteVersion, err := fetchRemoteVersion(ctx, p.remote)
+	var remoteVersion int64
+	retries, err := opt.retryPolicy().do(ctx, func() (err error) {
+		remoteVersion, err = fetchRemoteVersion(ctx, p.remote)
+		return
+	})
+	status.Retries += retries
 	if err != nil {
-		return nil, err
+		return &status, err
 	}
 	status.RemoteVersion = remoteVersion
+	logger.Debug("fetched remote version", "local_version", version, "remote_version", remoteVersion)
 
 	// skip if not modified
 	if skipSync(version, remoteVersion) {
@@ -64,18 +96,58 @@ func (p *Producer) Produce(ctx context.Context, version int64, opt *WriterOption
 	}
 	opt.Version = version
 
+	// check schema compatibility
+	if opt.Schema != nil {
+		if opt.SchemaRemote == nil {
+			return &status, errNoSchemaRemote
+		}
+
+		var enc *EncodedSchema
+		retries, err = opt.retryPolicy().do(ctx, func() (err error) {
+			enc, err = fetchSchema(ctx, opt.SchemaRemote)
+			return
+		})
+		status.Retries += retries
+		if err != nil {
+			return &status, err
+		}
+
+		var prev Schema
+		if enc != nil {
+			if prev, err = enc.Decode(); err != nil {
+				return &status, err
+			}
+		}
+		if err := checkSchema(opt.Schema, prev); err != nil {
+			return &status, err
+		}
+	}
+
 	// init writer and perform
+	retries, err = opt.retryPolicy().do(ctx, func() error {
+		writer, err := p.write(ctx, opt, pfn)
+		if writer != nil {
+			status.addWriter(writer)
+		}
+		return err
+	})
+	status.Retries += retries
+	if err != nil {
+		return &status, err
+	}
+
+	return &status, nil
+}
+
+func (p *Producer) write(ctx context.Context, opt *WriterOptions, pfn ProduceFunc) (*Writer, error) {
 	writer := NewWriter(ctx, p.remote, opt)
 	defer writer.Discard()
 
-	if err := pfn(writer); err != nil {
-		return nil, err
+	if err := traceCallback(ctx, func() error { return pfn(writer) }); err != nil {
+		return nil, permanentError{err}
 	}
-
 	if err := writer.Commit(); err != nil {
 		return nil, err
 	}
-
-	status.NumItems = writer.NumWritten()
-	return &status, nil
+	return writer, nil
 }
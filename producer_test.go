@@ -19,6 +19,11 @@ func TestProducer(t *testing.T) {
 	testProduce(t, pcr, 101, &feedx.Status{
 		LocalVersion: 101,
 		NumItems:     10,
+
+		NumBytes:           370,
+		NumCompressedBytes: 370,
+		NumFiles:           1,
+		Objects:            []string{"path/to/file.json"},
 	})
 
 	// second attempt
@@ -33,6 +38,11 @@ func TestProducer(t *testing.T) {
 		LocalVersion:  134,
 		RemoteVersion: 101,
 		NumItems:      13,
+
+		NumBytes:           481,
+		NumCompressedBytes: 481,
+		NumFiles:           1,
+		Objects:            []string{"path/to/file.json"},
 	})
 
 	meta, err := obj.Head(t.Context())
@@ -44,6 +54,36 @@ func TestProducer(t *testing.T) {
 	}
 }
 
+func TestProducer_compressed(t *testing.T) {
+	bucket := bfs.NewInMem()
+	obj := bfs.NewObjectFromBucket(bucket, "path/to/file.jsonz")
+	defer obj.Close()
+
+	pcr := feedx.NewProducerForRemote(obj)
+	defer pcr.Close()
+
+	status, err := pcr.Produce(t.Context(), 101, nil, func(w *feedx.Writer) error {
+		for i := 0; i < 10; i++ {
+			if err := w.Encode(seed()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if exp, got := int64(370), status.NumBytes; exp != got {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+	if got := status.NumCompressedBytes; got == 0 || got >= status.NumBytes {
+		t.Errorf("expected compressed bytes to be less than %v, got %v", status.NumBytes, got)
+	}
+	if sizes := bucket.ObjectSizes(); sizes["path/to/file.jsonz"] != status.NumCompressedBytes {
+		t.Errorf("expected %v, got %v", status.NumCompressedBytes, sizes)
+	}
+}
+
 func testProduce(t *testing.T, pcr *feedx.Producer, version int64, exp *feedx.Status) {
 	t.Helper()
 
@@ -59,7 +99,7 @@ func testProduce(t *testing.T, pcr *feedx.Producer, version int64, exp *feedx.St
 		t.Fatal("unexpected error", err)
 	}
 
-	if !reflect.DeepEqual(exp, status) {
+	if status := stripTimings(t, status); !reflect.DeepEqual(exp, status) {
 		t.Errorf("expected %#v, got %#v", exp, status)
 	}
 }
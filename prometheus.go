@@ -0,0 +1,154 @@
+package feedx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PrometheusMetrics aggregates sync metrics in memory and exposes them in
+// the Prometheus text exposition format. It implements http.Handler and
+// can be mounted as a scrape endpoint.
+type PrometheusMetrics struct {
+	namespace string
+
+	mu    sync.Mutex
+	feeds map[promFeedKey]*promFeedMetrics
+}
+
+type promFeedKey struct {
+	feed string
+	op   SyncOp
+}
+
+type promFeedMetrics struct {
+	syncs, skips, errors      int64
+	items, bytes, compressed  int64
+	durationSum               float64
+	versionLag                int64
+	lastSuccess, lastSyncTime time.Time
+}
+
+// NewPrometheusMetrics inits new metrics. All metric names are prefixed
+// with the namespace.
+// Default namespace: "feedx"
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	if namespace == "" {
+		namespace = "feedx"
+	}
+	return &PrometheusMetrics{
+		namespace: namespace,
+		feeds:     make(map[promFeedKey]*promFeedMetrics),
+	}
+}
+
+// ObserveSync implements Metrics.
+func (m *PrometheusMetrics) ObserveSync(ev *SyncEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := promFeedKey{feed: ev.Feed, op: ev.Op}
+	fm, ok := m.feeds[key]
+	if !ok {
+		fm = new(promFeedMetrics)
+		m.feeds[key] = fm
+	}
+
+	now := time.Now()
+	fm.syncs++
+	fm.durationSum += ev.Duration.Seconds()
+	fm.items += ev.NumItems
+	fm.bytes += ev.NumBytes
+	fm.compressed += ev.NumCompressedBytes
+	fm.versionLag = ev.VersionLag
+	fm.lastSyncTime = now
+
+	if ev.Err != nil {
+		fm.errors++
+	} else {
+		fm.lastSuccess = now
+		if ev.Skipped {
+			fm.skips++
+		}
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (m *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format.
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	keys := make([]promFeedKey, 0, len(m.feeds))
+	snap := make(map[promFeedKey]promFeedMetrics, len(m.feeds))
+	for key, fm := range m.feeds {
+		keys = append(keys, key)
+		snap[key] = *fm
+	}
+	m.mu.Unlock()
+
+	slices.SortFunc(keys, func(a, b promFeedKey) int {
+		if c := strings.Compare(a.feed, b.feed); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.op), string(b.op))
+	})
+
+	cw := &countingWriter{w: w, n: new(int64)}
+	bw := bufio.NewWriter(cw)
+
+	for _, def := range []struct {
+		name, typ, help string
+		value           func(*promFeedMetrics) (float64, bool)
+	}{
+		{"syncs_total", "counter", "Total number of sync attempts.", func(fm *promFeedMetrics) (float64, bool) { return float64(fm.syncs), true }},
+		{"skips_total", "counter", "Total number of skipped syncs.", func(fm *promFeedMetrics) (float64, bool) { return float64(fm.skips), true }},
+		{"errors_total", "counter", "Total number of failed syncs.", func(fm *promFeedMetrics) (float64, bool) { return float64(fm.errors), true }},
+		{"sync_duration_seconds_total", "counter", "Total time spent syncing.", func(fm *promFeedMetrics) (float64, bool) { return fm.durationSum, true }},
+		{"items_total", "counter", "Total number of items read or written.", func(fm *promFeedMetrics) (float64, bool) { return float64(fm.items), true }},
+		{"bytes_total", "counter", "Total number of uncompressed bytes read or written.", func(fm *promFeedMetrics) (float64, bool) { return float64(fm.bytes), true }},
+		{"compressed_bytes_total", "counter", "Total number of compressed bytes read or written.", func(fm *promFeedMetrics) (float64, bool) { return float64(fm.compressed), true }},
+		{"version_lag", "gauge", "Version difference between local and remote after the last sync.", func(fm *promFeedMetrics) (float64, bool) { return float64(fm.versionLag), true }},
+		{"last_sync_timestamp_seconds", "gauge", "Time of the last sync attempt.", func(fm *promFeedMetrics) (float64, bool) {
+			return promTimestamp(fm.lastSyncTime)
+		}},
+		{"last_success_timestamp_seconds", "gauge", "Time of the last successful sync.", func(fm *promFeedMetrics) (float64, bool) {
+			return promTimestamp(fm.lastSuccess)
+		}},
+	} {
+		name := m.namespace + "_" + def.name
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", name, def.help, name, def.typ)
+
+		for _, key := range keys {
+			fm := snap[key]
+			if v, ok := def.value(&fm); ok {
+				fmt.Fprintf(bw, "%s{feed=%s,op=%s} %s\n", name, promLabel(key.feed), promLabel(string(key.op)), strconv.FormatFloat(v, 'g', -1, 64))
+			}
+		}
+	}
+
+	err := bw.Flush()
+	return *cw.n, err
+}
+
+func promTimestamp(t time.Time) (float64, bool) {
+	if t.IsZero() {
+		return 0, false
+	}
+	return float64(t.UnixMilli()) / 1000, true
+}
+
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func promLabel(s string) string {
+	return `"` + promLabelEscaper.Replace(s) + `"`
+}
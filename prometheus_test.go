@@ -0,0 +1,55 @@
+package feedx_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bsm/feedx"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	metrics := feedx.NewPrometheusMetrics("")
+	metrics.ObserveSync(&feedx.SyncEvent{Feed: "s3://bucket/feed.json", Op: feedx.SyncOpProduce, Duration: time.Second, NumItems: 10, NumBytes: 200, NumCompressedBytes: 100})
+	metrics.ObserveSync(&feedx.SyncEvent{Feed: "s3://bucket/feed.json", Op: feedx.SyncOpProduce, Duration: time.Second / 2, Skipped: true})
+	metrics.ObserveSync(&feedx.SyncEvent{Feed: `a "quoted" feed`, Op: feedx.SyncOpConsume, VersionLag: 7, Err: errors.New("failed!")})
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if exp, got := "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"); exp != got {
+		t.Errorf("expected %q, got %q", exp, got)
+	}
+
+	body := rec.Body.String()
+	for _, exp := range []string{
+		"# TYPE feedx_syncs_total counter\n",
+		`feedx_syncs_total{feed="a \"quoted\" feed",op="consume"} 1` + "\n",
+		`feedx_syncs_total{feed="s3://bucket/feed.json",op="produce"} 2` + "\n",
+		`feedx_skips_total{feed="s3://bucket/feed.json",op="produce"} 1` + "\n",
+		`feedx_errors_total{feed="a \"quoted\" feed",op="consume"} 1` + "\n",
+		`feedx_sync_duration_seconds_total{feed="s3://bucket/feed.json",op="produce"} 1.5` + "\n",
+		`feedx_items_total{feed="s3://bucket/feed.json",op="produce"} 10` + "\n",
+		`feedx_bytes_total{feed="s3://bucket/feed.json",op="produce"} 200` + "\n",
+		`feedx_compressed_bytes_total{feed="s3://bucket/feed.json",op="produce"} 100` + "\n",
+		"# TYPE feedx_version_lag gauge\n",
+		`feedx_version_lag{feed="a \"quoted\" feed",op="consume"} 7` + "\n",
+		`feedx_last_success_timestamp_seconds{feed="s3://bucket/feed.json",op="produce"} `,
+	} {
+		if !strings.Contains(body, exp) {
+			t.Errorf("expected output to contain %q, got:\n%s", exp, body)
+		}
+	}
+
+	// failed feeds have never succeeded
+	if unexp := `feedx_last_success_timestamp_seconds{feed="a \"quoted\" feed"`; strings.Contains(body, unexp) {
+		t.Errorf("expected output not to contain %q", unexp)
+	}
+
+	// feeds are sorted
+	if a, b := strings.Index(body, `feedx_syncs_total{feed="a`), strings.Index(body, `feedx_syncs_total{feed="s3`); a > b {
+		t.Errorf("expected feeds to be sorted, got:\n%s", body)
+	}
+}
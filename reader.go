@@ -2,10 +2,16 @@ package feedx
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 
 	"github.com/bsm/bfs"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // ReaderOptions configure the reader instance.
@@ -17,6 +23,34 @@ type ReaderOptions struct {
 	// Compression specifies the compression type.
 	// Default: auto-detected from URL path.
 	Compression Compression
+
+	// Retry specifies the policy for resuming reads after transient
+	// failures. Interrupted remotes are reopened at the last byte offset.
+	// Default: nil (no retries)
+	Retry *RetryPolicy
+
+	// Metrics records consumer sync metrics.
+	// Default: nil (disabled)
+	Metrics Metrics
+
+	// Logger logs sync attempts and remote operations.
+	// Default: nil (disabled)
+	Logger *slog.Logger
+
+	// Feed identifies the feed in metrics, logs and traces. It should be set
+	// for consumers created from buckets, which are otherwise all labeled
+	// after their manifest.
+	// Default: the URL the consumer was created with, or the remote name.
+	Feed string
+
+	// MessageType specifies the protobuf message type of the records. If set,
+	// values decoded into a *proto.Message are populated with new messages of
+	// this type, e.g. when iterating over Records[proto.Message]. Only
+	// supported by ProtobufFormat and JSONFormat, JSON records are unmarshaled
+	// using protojson. Use ResolveMessageType to decode feeds of types which
+	// are not compiled into the binary as dynamicpb messages.
+	// Default: nil (values must be concrete proto.Message types)
+	MessageType protoreflect.MessageType
 }
 
 func (o *ReaderOptions) norm(name string) {
@@ -28,18 +62,53 @@ func (o *ReaderOptions) norm(name string) {
 	}
 }
 
+func (o *ReaderOptions) retryPolicy() *RetryPolicy {
+	if o == nil {
+		return nil
+	}
+	return o.Retry
+}
+
+func (o *ReaderOptions) metrics() Metrics {
+	if o == nil {
+		return nil
+	}
+	return o.Metrics
+}
+
+func (o *ReaderOptions) logger() *slog.Logger {
+	if o == nil {
+		return discardLogger
+	}
+	return loggerOrDiscard(o.Logger)
+}
+
+func (o *ReaderOptions) feed(name string) string {
+	if o == nil || o.Feed == "" {
+		return name
+	}
+	return o.Feed
+}
+
 // Reader reads data from a remote feed.
 type Reader struct {
 	ctx context.Context
 	opt *ReaderOptions
 
-	remotes    []*bfs.Object
-	ownRemotes bool
+	remotes     []*bfs.Object
+	ownRemotes  bool
+	incremental bool  // remotes are appended to, never rewritten
+	version     int64 // resolved by the consumer, if non-zero
 
 	cur *streamReader
 	pos int
 
-	num int64
+	num     int64
+	retries int
+
+	numBytes           int64 // uncompressed
+	numCompressedBytes int64
+	opened             []string // names of the remotes opened
 }
 
 // NewReader inits a new reader.
@@ -100,11 +169,38 @@ func (r *Reader) NumRead() int64 {
 	return r.num
 }
 
+// NumBytes returns the number of uncompressed bytes read.
+func (r *Reader) NumBytes() int64 {
+	return r.numBytes
+}
+
+// NumCompressedBytes returns the number of compressed bytes read from
+// the remotes.
+func (r *Reader) NumCompressedBytes() int64 {
+	return r.numCompressedBytes
+}
+
+// NumFiles returns the number of remotes opened for reading.
+func (r *Reader) NumFiles() int {
+	return len(r.opened)
+}
+
+// NumRetries returns the number of retries performed to resume
+// interrupted reads.
+func (r *Reader) NumRetries() int {
+	return r.retries
+}
+
 // Version returns the version of the remote feed.
 func (r *Reader) Version() (int64, error) {
 	var max int64
 	for _, remote := range r.remotes {
-		v, err := fetchRemoteVersion(r.ctx, remote)
+		var v int64
+		retries, err := r.opt.retryPolicy().do(r.ctx, func() (err error) {
+			v, err = fetchRemoteVersion(r.ctx, remote)
+			return
+		})
+		r.retries += retries
 		if err != nil {
 			return 0, err
 		} else if v > max {
@@ -115,6 +211,15 @@ func (r *Reader) Version() (int64, error) {
 	return max, nil
 }
 
+// consumedVersion returns the version resolved by the consumer, or falls
+// back to Version.
+func (r *Reader) consumedVersion() (int64, error) {
+	if r.version != 0 {
+		return r.version, nil
+	}
+	return r.Version()
+}
+
 // Close closes the reader.
 func (r *Reader) Close() (err error) {
 	if r.cur != nil {
@@ -130,6 +235,11 @@ func (r *Reader) Close() (err error) {
 	return
 }
 
+// skipRemotes skips the first n remotes, it must be called before reading.
+func (r *Reader) skipRemotes(n int) {
+	r.pos = min(n, len(r.remotes))
+}
+
 func (r *Reader) ensureCurrent() bool {
 	if r.pos >= len(r.remotes) {
 		return false
@@ -144,10 +254,15 @@ func (r *Reader) ensureCurrent() bool {
 		}
 		o.norm(remote.Name())
 
+		r.opened = append(r.opened, remote.Name())
 		r.cur = &streamReader{
-			remote: remote,
-			opt:    o,
-			ctx:    r.ctx,
+			remote:  remote,
+			opt:     o,
+			ctx:     r.ctx,
+			retries: &r.retries,
+
+			numBytes:           &r.numBytes,
+			numCompressedBytes: &r.numCompressedBytes,
 		}
 	}
 	return true
@@ -164,9 +279,15 @@ func (r *Reader) nextRemote() (bool, error) {
 }
 
 type streamReader struct {
-	remote *bfs.Object
-	opt    ReaderOptions
-	ctx    context.Context
+	remote  *bfs.Object
+	opt     ReaderOptions
+	ctx     context.Context
+	retries *int
+
+	numBytes           *int64 // uncompressed
+	numCompressedBytes *int64
+
+	span Span
 
 	br io.ReadCloser // bfs reader
 	cr io.ReadCloser // compression reader
@@ -176,26 +297,74 @@ type streamReader struct {
 // Read reads raw bytes from the feed.
 func (r *streamReader) Read(p []byte) (int, error) {
 	if err := r.ensureOpen(); err != nil {
+		r.recordError(err)
 		return 0, err
 	}
-	return r.cr.Read(p)
+
+	n, err := countingReader{r: r.cr, n: r.numBytes}.Read(p)
+	r.recordError(err)
+	return n, err
 }
 
 // Decode decodes the next formatted value from the feed.
 func (r *streamReader) Decode(v interface{}) error {
 	if err := r.ensureOpen(); err != nil {
+		r.recordError(err)
 		return err
 	}
 
 	if r.fd == nil {
-		fd, err := r.opt.Format.NewDecoder(r.cr)
+		fd, err := r.opt.Format.NewDecoder(countingReader{r: r.cr, n: r.numBytes})
 		if err != nil {
+			r.recordError(err)
 			return err
 		}
 		r.fd = fd
 	}
 
-	return r.fd.Decode(v)
+	err := r.decode(v)
+	r.recordError(err)
+	return err
+}
+
+func (r *streamReader) decode(v interface{}) error {
+	return decodeValue(r.opt.Format, r.fd, r.opt.MessageType, v)
+}
+
+// decodeValue decodes the next value. Values decoded into a *proto.Message
+// are populated with new messages of msgType, if set. JSON values are
+// unmarshaled using protojson, other formats are not supported.
+func decodeValue(format Format, fd FormatDecoder, msgType protoreflect.MessageType, v interface{}) error {
+	ptr, ok := v.(*proto.Message)
+	if !ok || msgType == nil {
+		return fd.Decode(v)
+	}
+
+	msg := msgType.New().Interface()
+	switch format {
+	case ProtobufFormat:
+		if err := fd.Decode(msg); err != nil {
+			return err
+		}
+	case JSONFormat:
+		var data json.RawMessage
+		if err := fd.Decode(&data); err != nil {
+			return err
+		}
+		if err := protojson.Unmarshal(data, msg); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("feedx: cannot decode %T records into protobuf messages", format)
+	}
+	*ptr = msg
+	return nil
+}
+
+func (r *streamReader) recordError(err error) {
+	if err != nil && !errors.Is(err, io.EOF) && r.span != nil {
+		r.span.RecordError(err)
+	}
 }
 
 // Close closes the reader.
@@ -216,11 +385,21 @@ func (r *streamReader) Close() error {
 			err = errors.Join(err, e)
 		}
 	}
+	if r.span != nil {
+		endSpan(r.span, err)
+		r.span = nil
+	}
 	return err
 }
 
 func (r *streamReader) ensureOpen() error {
-	if r.br == nil {
+	if r.span == nil {
+		r.ctx, r.span = startSpan(r.ctx, "feedx.read", slog.String("feedx.object", r.remote.Name()))
+	}
+
+	if r.br == nil && r.opt.Retry.maxAttempts() > 1 {
+		r.br = &resumableReader{remote: r.remote, policy: r.opt.Retry, ctx: r.ctx, total: r.retries}
+	} else if r.br == nil {
 		br, err := r.remote.Open(r.ctx)
 		if err != nil {
 			return err
@@ -229,12 +408,143 @@ func (r *streamReader) ensureOpen() error {
 	}
 
 	if r.cr == nil {
-		cr, err := r.opt.Compression.NewReader(r.br)
+		cr, err := r.opt.Compression.NewReader(countingReader{r: r.br, n: r.numCompressedBytes})
 		if err != nil {
 			return err
 		}
 		r.cr = cr
+		r.opt.logger().Debug("opened object", "object", r.remote.Name())
+	}
+
+	return nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (c countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	*c.n += int64(n)
+	return n, err
+}
+
+var errRemoteChanged = errors.New("feedx: remote changed while reading")
+
+// resumableReader reopens the remote at the last byte offset after
+// transient read failures.
+type resumableReader struct {
+	remote *bfs.Object
+	policy *RetryPolicy
+	ctx    context.Context
+
+	rc      bfs.Reader
+	info    *bfs.MetaInfo
+	offset  int64
+	retries int  // consecutive retries
+	total   *int // total retries
+}
+
+// Read implements io.Reader.
+func (r *resumableReader) Read(p []byte) (int, error) {
+	for {
+		if r.rc == nil {
+			if err := r.open(); err != nil {
+				if r.retry(err) {
+					continue
+				}
+				return 0, err
+			}
+		}
+
+		n, err := r.rc.Read(p)
+		r.offset += int64(n)
+		if n > 0 {
+			r.retries = 0
+		}
+		if err == nil || errors.Is(err, io.EOF) {
+			return n, err
+		}
+
+		_ = r.rc.Close()
+		r.rc = nil
+
+		if !r.retry(err) {
+			return n, err
+		} else if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// Close implements io.Closer.
+func (r *resumableReader) Close() error {
+	if r.rc != nil {
+		return r.rc.Close()
+	}
+	return nil
+}
+
+// retry returns true if a failed attempt should be retried. It blocks
+// until the retry is due.
+func (r *resumableReader) retry(err error) bool {
+	if errors.Is(err, errRemoteChanged) {
+		return false
+	}
+
+	r.retries++
+	if !r.policy.next(r.ctx, r.retries, err) {
+		return false
+	}
+	if r.total != nil {
+		*r.total++
+	}
+	return true
+}
+
+func (r *resumableReader) open() error {
+	info, err := r.remote.Head(r.ctx)
+	if err != nil {
+		return err
+	}
+	if r.info == nil {
+		r.info = info
+	} else if info.Size != r.info.Size || info.Metadata.Get(metaVersion) != r.info.Metadata.Get(metaVersion) {
+		return errRemoteChanged
+	}
+
+	rc, err := r.remote.Open(r.ctx)
+	if err != nil {
+		return err
 	}
 
+	if err := skipTo(rc, r.offset); err != nil {
+		_ = rc.Close()
+		return err
+	}
+
+	r.rc = rc
+	return nil
+}
+
+// skipTo advances the reader to the offset, using range reads when the
+// reader supports seeking.
+func skipTo(rc bfs.Reader, offset int64) error {
+	if offset == 0 {
+		return nil
+	}
+
+	if s, ok := rc.(io.Seeker); ok {
+		_, err := s.Seek(offset, io.SeekStart)
+		return err
+	}
+
+	if _, err := io.CopyN(io.Discard, rc, offset); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
 	return nil
 }
@@ -1,6 +1,8 @@
 package feedx_test
 
 import (
+	"context"
+	"errors"
 	"io"
 	"reflect"
 	"testing"
@@ -96,6 +98,125 @@ func fixMultiReader(t *testing.T) *feedx.Reader {
 	return r
 }
 
+func TestReader_Retry(t *testing.T) {
+	for _, seekable := range []bool{false, true} {
+		bucket := &flakyBucket{InMem: bfs.NewInMem(), failAt: 40, seekable: seekable}
+		defer bucket.Close()
+
+		obj := bfs.NewObjectFromBucket(bucket, "path/to/file.json")
+		defer obj.Close()
+
+		if err := writeN(obj, 10, 101); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		t.Run("resumes", func(t *testing.T) {
+			bucket.numFailures = 1
+
+			r, err := feedx.NewReader(t.Context(), obj, &feedx.ReaderOptions{
+				Retry: &feedx.RetryPolicy{MaxAttempts: 2},
+			})
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			defer r.Close()
+
+			if exp, got := seedN(10), drainReader(t, r); !reflect.DeepEqual(exp, got) {
+				t.Errorf("expected %#v, got %#v", exp, got)
+			}
+			if exp, got := 0, bucket.numFailures; exp != got {
+				t.Errorf("expected %v, got %v", exp, got)
+			}
+		})
+
+		t.Run("gives up", func(t *testing.T) {
+			bucket.numFailures = 3
+
+			r, err := feedx.NewReader(t.Context(), obj, &feedx.ReaderOptions{
+				Retry: &feedx.RetryPolicy{MaxAttempts: 2},
+			})
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			defer r.Close()
+
+			if _, err := readMessages(r); !errors.Is(err, errFlaky) {
+				t.Errorf("expected %v, got %v", errFlaky, err)
+			}
+		})
+
+		t.Run("fails without retries", func(t *testing.T) {
+			bucket.numFailures = 1
+
+			r, err := feedx.NewReader(t.Context(), obj, nil)
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			defer r.Close()
+
+			if _, err := readMessages(r); !errors.Is(err, errFlaky) {
+				t.Errorf("expected %v, got %v", errFlaky, err)
+			}
+		})
+	}
+}
+
+var errFlaky = errors.New("connection reset")
+
+// flakyBucket fails reads at a given byte offset.
+type flakyBucket struct {
+	*bfs.InMem
+
+	failAt      int64
+	numFailures int
+	seekable    bool
+}
+
+func (b *flakyBucket) Open(ctx context.Context, name string) (bfs.Reader, error) {
+	rc, err := b.InMem.Open(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	fr := &flakyReader{ReadCloser: rc, bucket: b}
+	if b.seekable {
+		return &seekableFlakyReader{flakyReader: fr}, nil
+	}
+	return fr, nil
+}
+
+type flakyReader struct {
+	io.ReadCloser
+
+	bucket *flakyBucket
+	pos    int64
+}
+
+func (r *flakyReader) Read(p []byte) (int, error) {
+	if r.bucket.numFailures > 0 {
+		if r.pos == r.bucket.failAt {
+			r.bucket.numFailures--
+			return 0, errFlaky
+		} else if max := r.bucket.failAt - r.pos; max > 0 && int64(len(p)) > max {
+			p = p[:max]
+		}
+	}
+
+	n, err := r.ReadCloser.Read(p)
+	r.pos += int64(n)
+	return n, err
+}
+
+type seekableFlakyReader struct {
+	*flakyReader
+}
+
+func (r *seekableFlakyReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.ReadCloser.(io.Seeker).Seek(offset, whence)
+	r.pos = pos
+	return pos, err
+}
+
 func drainReader(t *testing.T, r interface{ Decode(any) error }) []*testdata.MockMessage {
 	t.Helper()
 
@@ -0,0 +1,106 @@
+package feedx
+
+import (
+	"errors"
+	"io"
+	"iter"
+	"reflect"
+)
+
+// Records returns an iterator over the values decoded from the reader.
+// Iteration ends at the end of the feed or after the first error, which
+// is yielded together with a zero value.
+//
+// If T is a pointer type, e.g. *pb.Msg, a fresh value is allocated for
+// every item. All other values, including those of interface types, are
+// decoded into a *T. For T of proto.Message, ReaderOptions.MessageType
+// must be set to allocate messages, other interface types, e.g. any,
+// receive values as decoded by the format.
+func Records[T any](r *Reader) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		dec := newRecordDecoder[T]()
+		for {
+			v, err := dec(r)
+			if errors.Is(err, io.EOF) {
+				return
+			} else if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Batches returns an iterator over batches of up to size values decoded
+// from the reader. Each yielded batch is a newly allocated slice, the last
+// batch may contain fewer than size values. Iteration ends at the end of
+// the feed or after the first error, which is yielded together with a nil
+// batch.
+func Batches[T any](r *Reader, size int) iter.Seq2[[]T, error] {
+	if size < 1 {
+		size = 1
+	}
+
+	return func(yield func([]T, error) bool) {
+		batch := make([]T, 0, size)
+		for v, err := range Records[T](r) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if batch = append(batch, v); len(batch) == size {
+				if !yield(batch, nil) {
+					return
+				}
+				batch = make([]T, 0, size)
+			}
+		}
+
+		if len(batch) != 0 {
+			yield(batch, nil)
+		}
+	}
+}
+
+// ConsumeRecords returns a ConsumeFunc which decodes each value of the
+// feed and passes it to fn. Consumption stops on the first error.
+func ConsumeRecords[T any](fn func(T) error) ConsumeFunc {
+	return func(r *Reader) error {
+		for v, err := range Records[T](r) {
+			if err != nil {
+				return err
+			}
+			if err := fn(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// valueDecoder decodes values, it is implemented by Reader.
+type valueDecoder interface {
+	Decode(v interface{}) error
+}
+
+func newRecordDecoder[T any]() func(valueDecoder) (T, error) {
+	if typ := reflect.TypeFor[T](); typ.Kind() == reflect.Pointer {
+		elem := typ.Elem()
+		return func(r valueDecoder) (T, error) {
+			v := reflect.New(elem).Interface().(T)
+			return v, r.Decode(v)
+		}
+	}
+
+	return func(r valueDecoder) (T, error) {
+		var v T
+		err := r.Decode(&v)
+		return v, err
+	}
+}
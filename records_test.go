@@ -0,0 +1,203 @@
+package feedx_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+	"github.com/bsm/feedx/internal/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestRecords(t *testing.T) {
+	t.Run("decodes messages", func(t *testing.T) {
+		r := fixReader(t)
+
+		var msgs []*testdata.MockMessage
+		for msg, err := range feedx.Records[*testdata.MockMessage](r) {
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			msgs = append(msgs, msg)
+		}
+		if exp := seedN(3); !reflect.DeepEqual(exp, msgs) {
+			t.Errorf("expected %#v, got %#v", exp, msgs)
+		}
+		if exp, got := int64(3), r.NumRead(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("decodes protobuf", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.pb")
+		if err := writeN(obj, 3, 0); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		r, err := feedx.NewReader(t.Context(), obj, nil)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		defer r.Close()
+
+		var msgs []*testdata.MockMessage
+		for msg, err := range feedx.Records[*testdata.MockMessage](r) {
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			msgs = append(msgs, msg)
+		}
+		if exp, got := 3, len(msgs); exp != got {
+			t.Fatalf("expected %v, got %v", exp, got)
+		}
+		if msgs[0] == msgs[1] {
+			t.Error("expected fresh messages")
+		}
+	})
+
+	t.Run("decodes dynamic protobuf", func(t *testing.T) {
+		msgType, err := feedx.ResolveMessageType("feedx.internal.testdata.MockMessage", &descriptorpb.FileDescriptorSet{
+			File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(testdata.File_internal_testdata_testdata_proto)},
+		})
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		for _, ext := range []string{"pb", "json"} {
+			obj := bfs.NewInMemObject("path/to/file." + ext)
+			if err := writeN(obj, 3, 0); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+
+			r, err := feedx.NewReader(t.Context(), obj, &feedx.ReaderOptions{MessageType: msgType})
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			defer r.Close()
+
+			var msgs []proto.Message
+			for msg, err := range feedx.Records[proto.Message](r) {
+				if err != nil {
+					t.Fatal("unexpected error", err)
+				}
+				msgs = append(msgs, msg)
+			}
+			if exp, got := 3, len(msgs); exp != got {
+				t.Fatalf("expected %v, got %v", exp, got)
+			}
+			if _, ok := msgs[0].(*dynamicpb.Message); !ok {
+				t.Fatalf("expected a dynamic message, got %T", msgs[0])
+			}
+			if exp, got := "Joe", msgs[0].ProtoReflect().Get(msgType.Descriptor().Fields().ByName("name")).String(); exp != got {
+				t.Errorf("expected %v, got %v (%s)", exp, got, ext)
+			}
+		}
+
+		obj := bfs.NewInMemObject("path/to/file.cbor")
+		if err := writeN(obj, 3, 0); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		r, err := feedx.NewReader(t.Context(), obj, &feedx.ReaderOptions{MessageType: msgType})
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		defer r.Close()
+
+		var msg proto.Message
+		if err := r.Decode(&msg); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("decodes values", func(t *testing.T) {
+		r := fixReader(t)
+
+		var names []string
+		for v, err := range feedx.Records[map[string]any](r) {
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			names = append(names, v["name"].(string))
+		}
+		if exp := []string{"Joe", "Joe", "Joe"}; !reflect.DeepEqual(exp, names) {
+			t.Errorf("expected %v, got %v", exp, names)
+		}
+	})
+
+	t.Run("stops early", func(t *testing.T) {
+		r := fixReader(t)
+		for range feedx.Records[*testdata.MockMessage](r) {
+			break
+		}
+		if exp, got := int64(1), r.NumRead(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("yields errors", func(t *testing.T) {
+		r := fixReader(t)
+
+		var numErrors int
+		for _, err := range feedx.Records[int](r) {
+			if err != nil {
+				numErrors++
+			}
+		}
+		if exp, got := 1, numErrors; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+}
+
+func TestBatches(t *testing.T) {
+	r := fixMultiReader(t)
+
+	var sizes []int
+	for batch, err := range feedx.Batches[*testdata.MockMessage](r, 4) {
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		sizes = append(sizes, len(batch))
+	}
+	if exp := []int{4, 2}; !reflect.DeepEqual(exp, sizes) {
+		t.Errorf("expected %v, got %v", exp, sizes)
+	}
+}
+
+func TestConsumeRecords(t *testing.T) {
+	t.Run("consumes", func(t *testing.T) {
+		csm := fixConsumer(t, 101)
+
+		var msgs []*testdata.MockMessage
+		status, err := csm.Consume(t.Context(), nil, feedx.ConsumeRecords(func(msg *testdata.MockMessage) error {
+			msgs = append(msgs, msg)
+			return nil
+		}))
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp := seedN(2); !reflect.DeepEqual(exp, msgs) {
+			t.Errorf("expected %#v, got %#v", exp, msgs)
+		}
+		if exp, got := int64(2), status.NumItems; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("fails", func(t *testing.T) {
+		csm := fixConsumer(t, 101)
+
+		exp := errors.New("failed!")
+		_, err := csm.Consume(t.Context(), nil, feedx.ConsumeRecords(func(msg *testdata.MockMessage) error {
+			return exp
+		}))
+		if !errors.Is(err, exp) {
+			t.Errorf("expected %v, got %v", exp, err)
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package feedx
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/bsm/bfs"
+)
+
+// RetryPolicy configures retries of failed remote operations.
+type RetryPolicy struct {
+	// MaxAttempts specifies the maximum number of attempts, including the
+	// first one.
+	// Default: 1 (no retries)
+	MaxAttempts int
+
+	// Backoff specifies the delay before the first retry. The delay is
+	// doubled with every subsequent retry.
+	// Default: 0 (retry immediately)
+	Backoff time.Duration
+
+	// MaxBackoff limits the delay between retries.
+	// Default: 0 (unlimited)
+	MaxBackoff time.Duration
+
+	// Jitter randomly reduces each delay by up to the given fraction,
+	// e.g. 0.2 for up to 20%.
+	// Default: 0 (no jitter)
+	Jitter float64
+
+	// Retryable classifies errors as retryable.
+	// Default: all errors except context cancellations and bfs.ErrNotFound.
+	Retryable func(error) bool
+
+	// OnRetry is called before each retry with the number of the retry,
+	// starting at 1, and the error of the failed attempt.
+	OnRetry func(retry int, err error)
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// retryable returns true if err is eligible for retries.
+func (p *RetryPolicy) retryable(err error) bool {
+	if errors.As(err, new(permanentError)) {
+		return false
+	}
+	if errors.Is(err, ErrInvalidRecord) {
+		return false
+	}
+	if p != nil && p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return !errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded) &&
+		!errors.Is(err, bfs.ErrNotFound)
+}
+
+// backoff returns the delay before the given retry, starting at 1.
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	delay := shiftDuration(p.Backoff, retry-1)
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(p.Jitter * rand.Float64() * float64(delay))
+	}
+	return delay
+}
+
+// wait blocks before the given retry, starting at 1.
+func (p *RetryPolicy) wait(ctx context.Context, retry int) error {
+	delay := p.backoff(retry)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// next reports whether a failed attempt should be followed by the given
+// retry, starting at 1. It blocks until the retry is due.
+func (p *RetryPolicy) next(ctx context.Context, retry int, err error) bool {
+	if retry >= p.maxAttempts() || !p.retryable(err) {
+		return false
+	}
+	if p.OnRetry != nil {
+		p.OnRetry(retry, err)
+	}
+	return p.wait(ctx, retry) == nil
+}
+
+// do calls fn until it succeeds, fails with a non-retryable error or
+// the maximum number of attempts is reached. It returns the number of
+// retries performed.
+func (p *RetryPolicy) do(ctx context.Context, fn func() error) (int, error) {
+	for retry := 1; ; retry++ {
+		err := fn()
+		if err == nil {
+			return retry - 1, nil
+		}
+		if !p.next(ctx, retry, err) {
+			// strip the marker, wrapped markers are transparent
+			if pe, ok := err.(permanentError); ok {
+				err = pe.error
+			}
+			return retry - 1, err
+		}
+	}
+}
+
+// shiftDuration returns d doubled n times, saturating at the maximum
+// duration instead of overflowing.
+func shiftDuration(d time.Duration, n int) time.Duration {
+	if d <= 0 || n <= 0 {
+		return d
+	}
+	if n >= 63 || d > math.MaxInt64>>n {
+		return math.MaxInt64
+	}
+	return d << n
+}
+
+// permanentError marks errors which must never be retried, e.g. errors
+// returned by user callbacks.
+type permanentError struct{ error }
+
+func (e permanentError) Unwrap() error { return e.error }
@@ -0,0 +1,251 @@
+package feedx_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	t.Run("producer", func(t *testing.T) {
+		bucket := &unreliableBucket{InMem: bfs.NewInMem(), numHeadFailures: 1, numCommitFailures: 1}
+		obj := bfs.NewObjectFromBucket(bucket, "path/to/file.json")
+		defer obj.Close()
+
+		pcr := feedx.NewProducerForRemote(obj)
+		defer pcr.Close()
+
+		var retries []int
+		status, err := pcr.Produce(t.Context(), 101, &feedx.WriterOptions{
+			Retry: &feedx.RetryPolicy{
+				MaxAttempts: 3,
+				OnRetry:     func(n int, _ error) { retries = append(retries, n) },
+			},
+		}, func(w *feedx.Writer) error {
+			return w.Encode(seed())
+		})
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp := (&feedx.Status{
+			LocalVersion: 101,
+			NumItems:     1,
+			Retries:      2,
+
+			NumBytes:           37,
+			NumCompressedBytes: 37,
+			NumFiles:           1,
+			Objects:            []string{"path/to/file.json"},
+		}); !reflect.DeepEqual(exp, stripTimings(t, status)) {
+			t.Errorf("expected %#v, got %#v", exp, status)
+		}
+		if exp := []int{1, 1}; !reflect.DeepEqual(exp, retries) {
+			t.Errorf("expected %v, got %v", exp, retries)
+		}
+	})
+
+	t.Run("producer gives up", func(t *testing.T) {
+		bucket := &unreliableBucket{InMem: bfs.NewInMem(), numCommitFailures: 3}
+		obj := bfs.NewObjectFromBucket(bucket, "path/to/file.json")
+		defer obj.Close()
+
+		pcr := feedx.NewProducerForRemote(obj)
+		defer pcr.Close()
+
+		_, err := pcr.Produce(t.Context(), 101, &feedx.WriterOptions{
+			Retry: &feedx.RetryPolicy{MaxAttempts: 3},
+		}, func(w *feedx.Writer) error {
+			return w.Encode(seed())
+		})
+		if !errors.Is(err, errUnreliable) {
+			t.Errorf("expected %v, got %v", errUnreliable, err)
+		}
+	})
+
+	t.Run("producer does not retry callbacks", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		pcr := feedx.NewProducerForRemote(obj)
+		defer pcr.Close()
+
+		exp := errors.New("failed!")
+		numCalls := 0
+		_, err := pcr.Produce(t.Context(), 101, &feedx.WriterOptions{
+			Retry: &feedx.RetryPolicy{MaxAttempts: 3},
+		}, func(w *feedx.Writer) error {
+			numCalls++
+			return exp
+		})
+		if err != exp {
+			t.Errorf("expected %v, got %v", exp, err)
+		}
+		if numCalls != 1 {
+			t.Errorf("expected 1, got %v", numCalls)
+		}
+	})
+
+	t.Run("incremental producer", func(t *testing.T) {
+		bucket := &unreliableBucket{InMem: bfs.NewInMem(), numOpenFailures: 2, numCommitFailures: 1}
+		pcr := feedx.NewIncrementalProducerForBucket(bucket)
+		defer pcr.Close()
+
+		status, err := pcr.Produce(t.Context(), 101, &feedx.WriterOptions{
+			Retry: &feedx.RetryPolicy{MaxAttempts: 3},
+		}, func(_ int64) feedx.ProduceFunc {
+			return func(w *feedx.Writer) error { return w.Encode(seed()) }
+		})
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp := (&feedx.Status{
+			LocalVersion: 101,
+			NumItems:     1,
+			Retries:      3,
+
+			NumBytes:           37,
+			NumCompressedBytes: 37,
+			NumFiles:           1,
+			Objects:            []string{"data-0-101.json", "manifest.json"},
+		}); !reflect.DeepEqual(exp, stripTimings(t, status)) {
+			t.Errorf("expected %#v, got %#v", exp, status)
+		}
+	})
+
+	t.Run("consumer", func(t *testing.T) {
+		bucket := &unreliableBucket{InMem: bfs.NewInMem()}
+		obj := bfs.NewObjectFromBucket(bucket, "path/to/file.json")
+		defer obj.Close()
+
+		if err := writeN(obj, 2, 101); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		csm := feedx.NewConsumerForRemote(obj)
+		defer csm.Close()
+
+		bucket.numHeadFailures = 2
+		status, err := csm.Consume(t.Context(), &feedx.ReaderOptions{
+			Retry: &feedx.RetryPolicy{MaxAttempts: 3},
+		}, func(r *feedx.Reader) error {
+			_, err := readMessages(r)
+			return err
+		})
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp := (&feedx.Status{
+			RemoteVersion: 101,
+			NumItems:      2,
+			Retries:       2,
+
+			NumBytes:           74,
+			NumCompressedBytes: 74,
+			NumFiles:           1,
+			Objects:            []string{"path/to/file.json"},
+		}); !reflect.DeepEqual(exp, stripTimings(t, status)) {
+			t.Errorf("expected %#v, got %#v", exp, status)
+		}
+	})
+
+	t.Run("custom classifier", func(t *testing.T) {
+		bucket := &unreliableBucket{InMem: bfs.NewInMem(), numHeadFailures: 1}
+		obj := bfs.NewObjectFromBucket(bucket, "path/to/file.json")
+		defer obj.Close()
+
+		csm := feedx.NewConsumerForRemote(obj)
+		defer csm.Close()
+
+		_, err := csm.Consume(t.Context(), &feedx.ReaderOptions{
+			Retry: &feedx.RetryPolicy{
+				MaxAttempts: 3,
+				Retryable:   func(err error) bool { return !errors.Is(err, errUnreliable) },
+			},
+		}, func(r *feedx.Reader) error { return nil })
+		if !errors.Is(err, errUnreliable) {
+			t.Errorf("expected %v, got %v", errUnreliable, err)
+		}
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		policy := &feedx.RetryPolicy{Backoff: time.Second}
+		for _, retry := range []int{1, 2, 10, 40, 63, 64, 1000} {
+			if got := feedx.RetryBackoff(policy, retry); got < time.Second {
+				t.Errorf("expected positive backoff for retry %d, got %v", retry, got)
+			}
+		}
+		if exp, got := 4*time.Second, feedx.RetryBackoff(policy, 3); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+
+		policy.MaxBackoff = time.Minute
+		if exp, got := time.Minute, feedx.RetryBackoff(policy, 1000); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("wrapped permanent errors", func(t *testing.T) {
+		err := fmt.Errorf("wrapped: %w", feedx.PermanentError(errUnreliable))
+		if feedx.IsRetryable(nil, err) {
+			t.Error("expected error not to be retryable")
+		}
+		if !feedx.IsRetryable(nil, errUnreliable) {
+			t.Error("expected error to be retryable")
+		}
+	})
+}
+
+var errUnreliable = errors.New("service unavailable")
+
+// unreliableBucket fails a number of remote operations.
+type unreliableBucket struct {
+	*bfs.InMem
+
+	numHeadFailures   int
+	numOpenFailures   int
+	numCommitFailures int
+}
+
+func (b *unreliableBucket) Head(ctx context.Context, name string) (*bfs.MetaInfo, error) {
+	if b.numHeadFailures > 0 {
+		b.numHeadFailures--
+		return nil, errUnreliable
+	}
+	return b.InMem.Head(ctx, name)
+}
+
+func (b *unreliableBucket) Open(ctx context.Context, name string) (bfs.Reader, error) {
+	if b.numOpenFailures > 0 {
+		b.numOpenFailures--
+		return nil, errUnreliable
+	}
+	return b.InMem.Open(ctx, name)
+}
+
+func (b *unreliableBucket) Create(ctx context.Context, name string, opts *bfs.WriteOptions) (bfs.Writer, error) {
+	w, err := b.InMem.Create(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &unreliableWriter{Writer: w, bucket: b}, nil
+}
+
+type unreliableWriter struct {
+	bfs.Writer
+	bucket *unreliableBucket
+}
+
+func (w *unreliableWriter) Commit() error {
+	if w.bucket.numCommitFailures > 0 {
+		w.bucket.numCommitFailures--
+		_ = w.Writer.Discard()
+		return errUnreliable
+	}
+	return w.Writer.Commit()
+}
@@ -0,0 +1,422 @@
+package feedx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/bsm/bfs"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ErrIncompatibleSchema is returned by producers if the schema of the
+// records is incompatible with the previously published schema.
+var ErrIncompatibleSchema = errors.New("feedx: incompatible schema")
+
+var errNoSchemaRemote = errors.New("feedx: schema requires a schema remote")
+
+// Schema describes the structure of feed records.
+type Schema interface {
+	// Type returns the schema type, as registered with RegisterSchemaType.
+	Type() string
+	// MarshalBinary encodes the schema.
+	MarshalBinary() ([]byte, error)
+	// CheckCompatible returns an error wrapping ErrIncompatibleSchema if
+	// consumers which expect records of the previous schema cannot read
+	// records of this schema.
+	CheckCompatible(prev Schema) error
+}
+
+var schemaTypes = struct {
+	sync.RWMutex
+	m map[string]func([]byte) (Schema, error)
+}{m: map[string]func([]byte) (Schema, error){
+	"protobuf":   func(data []byte) (Schema, error) { return unmarshalProtobufSchema(data) },
+	"jsonschema": func(data []byte) (Schema, error) { return JSONSchema(data) },
+}}
+
+// RegisterSchemaType registers a custom schema type with a function which
+// decodes schemas of the type.
+func RegisterSchemaType(typ string, unmarshal func([]byte) (Schema, error)) {
+	schemaTypes.Lock()
+	defer schemaTypes.Unlock()
+
+	schemaTypes.m[typ] = unmarshal
+}
+
+// UnmarshalSchema decodes a schema of a registered type.
+func UnmarshalSchema(typ string, data []byte) (Schema, error) {
+	schemaTypes.RLock()
+	unmarshal, ok := schemaTypes.m[typ]
+	schemaTypes.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("feedx: unknown schema type %q", typ)
+	}
+	return unmarshal(data)
+}
+
+// EncodedSchema holds an encoded schema, as stored in feed manifests.
+type EncodedSchema struct {
+	// Type is the schema type.
+	Type string `json:"type"`
+	// Data is the encoded schema.
+	Data []byte `json:"data"`
+}
+
+func encodeSchema(s Schema) (*EncodedSchema, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	data, err := s.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &EncodedSchema{Type: s.Type(), Data: data}, nil
+}
+
+// Decode decodes the schema. Returns nil if the receiver is nil.
+func (e *EncodedSchema) Decode() (Schema, error) {
+	if e == nil {
+		return nil, nil
+	}
+	return UnmarshalSchema(e.Type, e.Data)
+}
+
+// LoadSchema loads a schema from a remote object, as written next to the
+// feed by producers, see WriterOptions.SchemaRemote. It returns nil if the
+// object does not exist.
+func LoadSchema(ctx context.Context, obj *bfs.Object) (Schema, error) {
+	enc, err := fetchSchema(ctx, obj)
+	if err != nil || enc == nil {
+		return nil, err
+	}
+	return enc.Decode()
+}
+
+// fetchSchema fetches an encoded schema from a remote object. It returns nil
+// if the object does not exist.
+func fetchSchema(ctx context.Context, obj *bfs.Object) (_ *EncodedSchema, err error) {
+	ctx, span := startSpan(ctx, "feedx.load_schema", slog.String("feedx.object", obj.Name()))
+	defer func() { endSpan(span, err) }()
+
+	r, err := NewReader(ctx, obj, &ReaderOptions{Format: JSONFormat})
+	if errors.Is(err, bfs.ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	enc := new(EncodedSchema)
+	if err := r.Decode(enc); errors.Is(err, bfs.ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return enc, nil
+}
+
+// writeSchema writes an encoded schema to a remote object.
+func writeSchema(ctx context.Context, obj *bfs.Object, enc *EncodedSchema, opt *WriterOptions) error {
+	w := NewWriter(ctx, obj, &WriterOptions{
+		Format:  JSONFormat,
+		Version: opt.Version,
+		Logger:  opt.Logger,
+	})
+	defer w.Discard()
+
+	if err := w.Encode(enc); err != nil {
+		return err
+	}
+	return w.Commit()
+}
+
+// manifestSchema checks the compatibility of a schema with the schema of
+// the previous manifest and returns it in encoded form. The previous schema
+// is retained if schema is nil.
+func manifestSchema(schema Schema, prev *manifest) (*EncodedSchema, error) {
+	if schema == nil {
+		return prev.Schema, nil
+	}
+
+	prevSchema, err := prev.Schema.Decode()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSchema(schema, prevSchema); err != nil {
+		return nil, err
+	}
+	return encodeSchema(schema)
+}
+
+// checkSchema checks the compatibility of next with prev. Any of the two may
+// be nil.
+func checkSchema(next, prev Schema) error {
+	if next == nil || prev == nil {
+		return nil
+	}
+	if next.Type() != prev.Type() {
+		return fmt.Errorf("%w: schema type changed from %s to %s", ErrIncompatibleSchema, prev.Type(), next.Type())
+	}
+	return next.CheckCompatible(prev)
+}
+
+// --------------------------------------------------------------------
+
+// ProtobufSchema returns a schema for protobuf messages.
+//
+// Consumers of the previous schema can read records of a new schema as long
+// as no required fields are removed and the types of existing field numbers
+// remain wire-compatible.
+func ProtobufSchema(desc protoreflect.MessageDescriptor) Schema {
+	return protobufSchema{desc: desc}
+}
+
+type protobufSchema struct {
+	desc protoreflect.MessageDescriptor
+}
+
+// Type implements Schema.
+func (protobufSchema) Type() string { return "protobuf" }
+
+// MarshalBinary implements Schema. The encoding contains the full message
+// name and a FileDescriptorSet with all its transitive dependencies.
+func (s protobufSchema) MarshalBinary() ([]byte, error) {
+	fds := new(descriptorpb.FileDescriptorSet)
+	seen := make(map[string]bool)
+
+	var addFile func(protoreflect.FileDescriptor)
+	addFile = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			addFile(imports.Get(i).FileDescriptor)
+		}
+		fds.File = append(fds.File, protodesc.ToFileDescriptorProto(fd))
+	}
+	addFile(s.desc.ParentFile())
+
+	files, err := proto.Marshal(fds)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	data = protowire.AppendTag(data, 1, protowire.BytesType)
+	data = protowire.AppendString(data, string(s.desc.FullName()))
+	data = protowire.AppendTag(data, 2, protowire.BytesType)
+	data = protowire.AppendBytes(data, files)
+	return data, nil
+}
+
+// CheckCompatible implements Schema.
+func (s protobufSchema) CheckCompatible(prev Schema) error {
+	ps, ok := prev.(protobufSchema)
+	if !ok {
+		return fmt.Errorf("%w: schema type changed from %s to %s", ErrIncompatibleSchema, prev.Type(), s.Type())
+	}
+	return checkProtoMessage(s.desc, ps.desc, make(map[[2]protoreflect.FullName]bool))
+}
+
+func unmarshalProtobufSchema(data []byte) (Schema, error) {
+	var name string
+	var files []byte
+	for len(data) != 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(data)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			name, data = v, data[n:]
+		case num == 2 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			files, data = v, data[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			data = data[n:]
+		}
+	}
+
+	fds := new(descriptorpb.FileDescriptorSet)
+	if err := proto.Unmarshal(files, fds); err != nil {
+		return nil, err
+	}
+
+	msgType, err := ResolveMessageType(protoreflect.FullName(name), fds)
+	if err != nil {
+		return nil, err
+	}
+	return ProtobufSchema(msgType.Descriptor()), nil
+}
+
+func checkProtoMessage(next, prev protoreflect.MessageDescriptor, seen map[[2]protoreflect.FullName]bool) error {
+	key := [2]protoreflect.FullName{next.FullName(), prev.FullName()}
+	if seen[key] {
+		return nil
+	}
+	seen[key] = true
+
+	fields := prev.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		pf := fields.Get(i)
+		nf := next.Fields().ByNumber(pf.Number())
+		if nf == nil {
+			if pf.Cardinality() == protoreflect.Required {
+				return fmt.Errorf("%w: required field %s was removed", ErrIncompatibleSchema, pf.FullName())
+			}
+			continue
+		}
+
+		if pf.Cardinality() == protoreflect.Required && nf.Cardinality() != protoreflect.Required {
+			return fmt.Errorf("%w: field %s is no longer required", ErrIncompatibleSchema, pf.FullName())
+		}
+		if (pf.Cardinality() == protoreflect.Repeated) != (nf.Cardinality() == protoreflect.Repeated) {
+			return fmt.Errorf("%w: field %s changed from %s to %s", ErrIncompatibleSchema, pf.FullName(), pf.Cardinality(), nf.Cardinality())
+		}
+		if protoWireClass(pf.Kind()) != protoWireClass(nf.Kind()) {
+			return fmt.Errorf("%w: field %s changed type from %s to %s", ErrIncompatibleSchema, pf.FullName(), pf.Kind(), nf.Kind())
+		}
+		if pf.Message() != nil {
+			if err := checkProtoMessage(nf.Message(), pf.Message(), seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// protoWireClass groups kinds which can be decoded interchangeably.
+func protoWireClass(kind protoreflect.Kind) protoreflect.Kind {
+	switch kind {
+	case protoreflect.BoolKind, protoreflect.EnumKind, protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Uint32Kind, protoreflect.Uint64Kind:
+		return protoreflect.Int64Kind
+	case protoreflect.Sint32Kind, protoreflect.Sint64Kind:
+		return protoreflect.Sint64Kind
+	case protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.Fixed32Kind
+	case protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.Fixed64Kind
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return protoreflect.BytesKind
+	default:
+		return kind
+	}
+}
+
+// --------------------------------------------------------------------
+
+// JSONSchema parses a JSON Schema document.
+//
+// Compatibility checks support a subset of JSON Schema: consumers of the
+// previous schema can read records of a new schema as long as all
+// previously required properties remain required and the types of
+// properties and array items are not widened. Other keywords are ignored.
+func JSONSchema(data []byte) (Schema, error) {
+	node := new(jsonSchemaNode)
+	if err := json.Unmarshal(data, node); err != nil {
+		return nil, err
+	}
+	return &jsonSchema{data: data, root: node}, nil
+}
+
+type jsonSchema struct {
+	data []byte
+	root *jsonSchemaNode
+}
+
+type jsonSchemaNode struct {
+	Type       jsonSchemaTypes            `json:"type"`
+	Properties map[string]*jsonSchemaNode `json:"properties"`
+	Required   []string                   `json:"required"`
+	Items      *jsonSchemaNode            `json:"items"`
+}
+
+// jsonSchemaTypes accepts a single type or a list of types.
+type jsonSchemaTypes []string
+
+func (t *jsonSchemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = jsonSchemaTypes{one}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+// Type implements Schema.
+func (*jsonSchema) Type() string { return "jsonschema" }
+
+// MarshalBinary implements Schema.
+func (s *jsonSchema) MarshalBinary() ([]byte, error) { return s.data, nil }
+
+// CheckCompatible implements Schema.
+func (s *jsonSchema) CheckCompatible(prev Schema) error {
+	ps, ok := prev.(*jsonSchema)
+	if !ok {
+		return fmt.Errorf("%w: schema type changed from %s to %s", ErrIncompatibleSchema, prev.Type(), s.Type())
+	}
+	return checkJSONSchemaNode(s.root, ps.root, "$")
+}
+
+func checkJSONSchemaNode(next, prev *jsonSchemaNode, path string) error {
+	if next == nil || prev == nil {
+		return nil
+	}
+
+	if len(prev.Type) != 0 {
+		if len(next.Type) == 0 {
+			return fmt.Errorf("%w: %s is no longer restricted to %v", ErrIncompatibleSchema, path, prev.Type)
+		}
+		for _, typ := range next.Type {
+			if !slices.Contains(prev.Type, typ) && (typ != "integer" || !slices.Contains(prev.Type, "number")) {
+				return fmt.Errorf("%w: %s changed type from %v to %v", ErrIncompatibleSchema, path, prev.Type, next.Type)
+			}
+		}
+	}
+
+	for _, name := range prev.Required {
+		if !slices.Contains(next.Required, name) {
+			return fmt.Errorf("%w: %s.%s is no longer required", ErrIncompatibleSchema, path, name)
+		}
+	}
+
+	for name, prop := range prev.Properties {
+		if err := checkJSONSchemaNode(next.Properties[name], prop, path+"."+name); err != nil {
+			return err
+		}
+	}
+	return checkJSONSchemaNode(next.Items, prev.Items, path+"[]")
+}
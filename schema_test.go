@@ -0,0 +1,280 @@
+package feedx_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestProtobufSchema(t *testing.T) {
+	base := []*descriptorpb.FieldDescriptorProto{
+		protoField("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+		protoField("height", 3, descriptorpb.FieldDescriptorProto_TYPE_UINT32),
+	}
+	prev := feedx.ProtobufSchema(seed().ProtoReflect().Descriptor())
+
+	t.Run("round-trips", func(t *testing.T) {
+		data, err := prev.MarshalBinary()
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		schema, err := feedx.UnmarshalSchema(prev.Type(), data)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if err := schema.CheckCompatible(prev); err != nil {
+			t.Error("unexpected error", err)
+		}
+		if err := prev.CheckCompatible(schema); err != nil {
+			t.Error("unexpected error", err)
+		}
+	})
+
+	t.Run("compatible", func(t *testing.T) {
+		for name, fields := range map[string][]*descriptorpb.FieldDescriptorProto{
+			"removed field": base,
+			"added field":   append(base, protoField("tags", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING)),
+			"widened int": {
+				protoField("name", 1, descriptorpb.FieldDescriptorProto_TYPE_BYTES),
+				protoField("height", 3, descriptorpb.FieldDescriptorProto_TYPE_UINT64),
+			},
+		} {
+			if err := protoSchema(t, fields).CheckCompatible(prev); err != nil {
+				t.Errorf("%s: unexpected error %v", name, err)
+			}
+		}
+	})
+
+	t.Run("incompatible", func(t *testing.T) {
+		repeated := protoField("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING)
+		repeated.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+
+		for name, fields := range map[string][]*descriptorpb.FieldDescriptorProto{
+			"changed type": {
+				protoField("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				protoField("height", 3, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE),
+			},
+			"repeated": {repeated},
+		} {
+			if err := protoSchema(t, fields).CheckCompatible(prev); !errors.Is(err, feedx.ErrIncompatibleSchema) {
+				t.Errorf("%s: expected %v, got %v", name, feedx.ErrIncompatibleSchema, err)
+			}
+		}
+	})
+}
+
+func TestJSONSchema(t *testing.T) {
+	prev := mustJSONSchema(t, `{
+		"type": "object",
+		"required": ["name"],
+		"properties": {
+			"name": {"type": "string"},
+			"height": {"type": "number"},
+			"tags": {"type": "array", "items": {"type": "string"}}
+		}
+	}`)
+
+	if _, err := feedx.JSONSchema([]byte(`{"type": 1}`)); err == nil {
+		t.Error("expected error")
+	}
+
+	for _, doc := range []string{
+		`{"type": "object", "required": ["name", "height"], "properties": {"name": {"type": "string"}}}`,
+		`{"type": "object", "required": ["name"], "properties": {"height": {"type": "integer"}}}`,
+		`{"type": ["object"], "required": ["name"], "properties": {"tags": {"type": "array", "items": {"type": "string"}}}}`,
+	} {
+		if err := mustJSONSchema(t, doc).CheckCompatible(prev); err != nil {
+			t.Errorf("expected %s to be compatible, got %v", doc, err)
+		}
+	}
+
+	for _, doc := range []string{
+		`{"type": "object", "properties": {"name": {"type": "string"}}}`,
+		`{"type": ["object", "null"], "required": ["name"]}`,
+		`{"type": "object", "required": ["name"], "properties": {"height": {"type": "string"}}}`,
+		`{"type": "object", "required": ["name"], "properties": {"tags": {"items": {"type": ["string", "number"]}}}}`,
+		`{"required": ["name"]}`,
+	} {
+		if err := mustJSONSchema(t, doc).CheckCompatible(prev); !errors.Is(err, feedx.ErrIncompatibleSchema) {
+			t.Errorf("expected %s to be incompatible, got %v", doc, err)
+		}
+	}
+}
+
+func TestProducer_Schema(t *testing.T) {
+	prev := feedx.ProtobufSchema(seed().ProtoReflect().Descriptor())
+	next := protoSchema(t, []*descriptorpb.FieldDescriptorProto{
+		protoField("name", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64),
+	})
+
+	t.Run("plain", func(t *testing.T) {
+		bucket := bfs.NewInMem()
+		defer bucket.Close()
+
+		obj := bfs.NewObjectFromBucket(bucket, "path/to/file.pb")
+		schemaObj := bfs.NewObjectFromBucket(bucket, "path/to/file.schema.json")
+
+		pcr := feedx.NewProducerForRemote(obj)
+		defer pcr.Close()
+
+		if _, err := pcr.Produce(t.Context(), 100, &feedx.WriterOptions{Schema: prev}, func(w *feedx.Writer) error {
+			t.Fatal("unexpected call")
+			return nil
+		}); err == nil {
+			t.Fatal("expected error")
+		}
+
+		if _, err := pcr.Produce(t.Context(), 101, &feedx.WriterOptions{Schema: prev, SchemaRemote: schemaObj}, func(w *feedx.Writer) error {
+			return w.Encode(seed())
+		}); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		r, err := feedx.NewReader(t.Context(), obj, nil)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		defer r.Close()
+
+		if schema, err := feedx.LoadSchema(t.Context(), schemaObj); err != nil {
+			t.Fatal("unexpected error", err)
+		} else if schema == nil {
+			t.Fatal("expected schema")
+		} else if err := schema.CheckCompatible(prev); err != nil {
+			t.Error("unexpected error", err)
+		}
+
+		if info, err := obj.Head(t.Context()); err != nil {
+			t.Fatal("unexpected error", err)
+		} else if exp, got := 1, len(info.Metadata); exp != got {
+			t.Errorf("expected %v, got %v (%v)", exp, got, info.Metadata)
+		}
+
+		if _, err := pcr.Produce(t.Context(), 102, &feedx.WriterOptions{Schema: next, SchemaRemote: schemaObj}, func(w *feedx.Writer) error {
+			t.Fatal("unexpected call")
+			return nil
+		}); !errors.Is(err, feedx.ErrIncompatibleSchema) {
+			t.Fatalf("expected %v, got %v", feedx.ErrIncompatibleSchema, err)
+		}
+		if version, err := r.Version(); err != nil {
+			t.Fatal("unexpected error", err)
+		} else if exp, got := int64(101), version; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("load errors", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.schema.json")
+		defer obj.Close()
+
+		if schema, err := feedx.LoadSchema(t.Context(), obj); err != nil {
+			t.Fatal("unexpected error", err)
+		} else if schema != nil {
+			t.Errorf("expected no schema, got %v", schema)
+		}
+
+		w, err := obj.Create(t.Context(), nil)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		defer w.Discard()
+
+		if _, err := w.Write([]byte(`{"type":"unknown"}`)); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		// errors are returned as is, not marked for the retry policy
+		if _, err := feedx.LoadSchema(t.Context(), obj); err == nil {
+			t.Fatal("expected error")
+		} else if !feedx.IsRetryable(nil, err) {
+			t.Errorf("expected %v to be unmarked", err)
+		}
+	})
+
+	t.Run("incremental", func(t *testing.T) {
+		bucket := bfs.NewInMem()
+		defer bucket.Close()
+
+		pcr := feedx.NewIncrementalProducerForBucket(bucket)
+		defer pcr.Close()
+
+		produce := func(version int64, schema feedx.Schema) error {
+			_, err := pcr.Produce(t.Context(), version, &feedx.WriterOptions{Schema: schema}, func(_ int64) feedx.ProduceFunc {
+				return func(w *feedx.Writer) error { return w.Encode(seed()) }
+			})
+			return err
+		}
+
+		if err := produce(101, prev); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if err := produce(102, next); !errors.Is(err, feedx.ErrIncompatibleSchema) {
+			t.Fatalf("expected %v, got %v", feedx.ErrIncompatibleSchema, err)
+		}
+		if err := produce(103, nil); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		mft, err := feedx.LoadManifest(t.Context(), bfs.NewObjectFromBucket(bucket, "manifest.json"))
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp, got := int64(103), mft.Version; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+
+		schema, err := mft.Schema.Decode()
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		} else if exp, got := "protobuf", schema.Type(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+}
+
+func protoField(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		Number:   proto.Int32(num),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     typ.Enum(),
+		JsonName: proto.String(name),
+	}
+}
+
+func protoSchema(t *testing.T, fields []*descriptorpb.FieldDescriptorProto) feedx.Schema {
+	t.Helper()
+
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("schema_test.proto"),
+		Package: proto.String("feedx.internal.testdata"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("MockMessage"), Field: fields},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	return feedx.ProtobufSchema(fd.Messages().ByName(protoreflect.Name("MockMessage")))
+}
+
+func mustJSONSchema(t *testing.T, doc string) feedx.Schema {
+	t.Helper()
+
+	schema, err := feedx.JSONSchema([]byte(doc))
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	return schema
+}
@@ -0,0 +1,148 @@
+package feedx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+
+	"github.com/bsm/bfs"
+)
+
+const metaSortedBy = "X-Feedx-Sorted-By"
+
+// SortOptions configure writers to sort records by key.
+type SortOptions struct {
+	// Name identifies the sort key, e.g. the name of the key field. It is
+	// published with the remote metadata, see Reader.SortedBy.
+	// Required.
+	Name string
+
+	// Key extracts the sort key of a record. Records are sorted by the
+	// byte-wise order of their keys, records with equal keys retain the
+	// order in which they were encoded.
+	// Required.
+	Key func(v interface{}) string
+
+	// MaxMemory limits the approximate number of bytes buffered in memory.
+	// Once exceeded, records are spilled as sorted runs to temporary files,
+	// which are merged on Commit.
+	// Default: 64 MiB
+	MaxMemory int
+
+	// TempDir specifies the directory for temporary files.
+	// Default: os.TempDir()
+	TempDir string
+}
+
+// SortedBy returns the name of the key the remote feed is sorted by, as
+// published by the producer. Multiple remotes are only sorted individually,
+// the name is returned if all remotes share the same sort key. Returns an
+// empty string if the feed is not sorted.
+func (r *Reader) SortedBy() (string, error) {
+	var name string
+	for i, remote := range r.remotes {
+		var s string
+		retries, err := r.opt.retryPolicy().do(r.ctx, func() (err error) {
+			s, err = fetchRemoteSortedBy(r.ctx, remote)
+			return
+		})
+		r.retries += retries
+		if err != nil {
+			return "", err
+		} else if i != 0 && s != name {
+			return "", nil
+		}
+		name = s
+	}
+	return name, nil
+}
+
+func fetchRemoteSortedBy(ctx context.Context, obj *bfs.Object) (string, error) {
+	info, err := obj.Head(ctx)
+	if errors.Is(err, bfs.ErrNotFound) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return info.Metadata.Get(metaSortedBy), nil
+}
+
+// --------------------------------------------------------------------
+
+// sortingEncoder encodes records individually and passes them to an
+// external sorter.
+type sortingEncoder struct {
+	opt    *SortOptions
+	sorter *extSorter
+	buf    bytes.Buffer
+	fe     FormatEncoder
+}
+
+func newSortingEncoder(format Format, opt *SortOptions) (*sortingEncoder, error) {
+	if opt.Key == nil {
+		return nil, errors.New("feedx: sorting requires a key function")
+	}
+
+	dir := opt.TempDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	e := &sortingEncoder{opt: opt, sorter: newExtSorter(dir, opt.MaxMemory)}
+	fe, err := format.NewEncoder(&e.buf)
+	if err != nil {
+		return nil, err
+	}
+	e.fe = fe
+	return e, nil
+}
+
+func (e *sortingEncoder) Encode(v interface{}) error {
+	e.buf.Reset()
+	if err := e.fe.Encode(v); err != nil {
+		return err
+	}
+	return e.Add(v, e.buf.Bytes())
+}
+
+// Add adds a value which has already been encoded.
+func (e *sortingEncoder) Add(v interface{}, data []byte) error {
+	return e.sorter.Add(e.opt.Key(v), bytes.Clone(data))
+}
+
+// WriteTo writes the encoded records to w, in key order.
+func (e *sortingEncoder) WriteTo(w *Writer) error {
+	it, err := e.sorter.Sort()
+	if err != nil {
+		return err
+	}
+
+	if err := w.ensureCreated(); err != nil {
+		return err
+	}
+	for {
+		entry, ok, err := nextEntry(it)
+		if err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+
+		if w.opt.Index != nil {
+			if err := w.beginRecord(entry.key); err != nil {
+				return err
+			}
+		}
+		if _, err := w.ww.Write(entry.value); err != nil {
+			return err
+		}
+	}
+
+	w.opt.logger().Debug("sorted records", "object", w.remote.Name(), "sorted_by", e.opt.Name, "runs", e.sorter.NumRuns())
+	return nil
+}
+
+func (e *sortingEncoder) Close() error {
+	return errors.Join(e.fe.Close(), e.sorter.Close())
+}
@@ -0,0 +1,253 @@
+package feedx_test
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+	"github.com/bsm/feedx/internal/testdata"
+)
+
+func TestWriter_SortBy(t *testing.T) {
+	sortBy := func(dir string) *feedx.SortOptions {
+		return &feedx.SortOptions{
+			Name:      "name",
+			Key:       func(v interface{}) string { return v.(*testdata.MockMessage).Name },
+			MaxMemory: 512,
+			TempDir:   dir,
+		}
+	}
+
+	write := func(t *testing.T, obj *bfs.Object, opt *feedx.WriterOptions) {
+		t.Helper()
+
+		w := feedx.NewWriter(t.Context(), obj, opt)
+		defer w.Discard()
+
+		for i := 0; i < 100; i++ {
+			msg := &testdata.MockMessage{Name: fmt.Sprintf("%02d", (i*37)%50), Height: uint32(i)}
+			if err := w.Encode(msg); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp, got := int64(100), w.NumWritten(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	}
+
+	for _, ext := range []string{"json", "pb", "cbor.gz"} {
+		t.Run(ext, func(t *testing.T) {
+			dir := t.TempDir()
+			obj := bfs.NewInMemObject("path/to/file." + ext)
+			defer obj.Close()
+
+			write(t, obj, &feedx.WriterOptions{SortBy: sortBy(dir)})
+
+			r, err := feedx.NewReader(t.Context(), obj, nil)
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			defer r.Close()
+
+			if name, err := r.SortedBy(); err != nil {
+				t.Fatal("unexpected error", err)
+			} else if exp, got := "name", name; exp != got {
+				t.Errorf("expected %v, got %v", exp, got)
+			}
+
+			var names []string
+			var prev *testdata.MockMessage
+			for msg, err := range feedx.Records[*testdata.MockMessage](r) {
+				if err != nil {
+					t.Fatal("unexpected error", err)
+				}
+				if prev != nil && prev.Name == msg.Name && prev.Height > msg.Height {
+					t.Errorf("expected stable order, got %v after %v", msg, prev)
+				}
+				names = append(names, msg.Name)
+				prev = msg
+			}
+			if exp, got := 100, len(names); exp != got {
+				t.Fatalf("expected %v, got %v", exp, got)
+			}
+			for i, name := range names {
+				if exp := fmt.Sprintf("%02d", i/2); exp != name {
+					t.Fatalf("expected %v, got %v at %d", exp, name, i)
+				}
+			}
+
+			if entries, err := os.ReadDir(dir); err != nil {
+				t.Fatal("unexpected error", err)
+			} else if len(entries) != 0 {
+				t.Errorf("expected temporary files to be removed, got %v", entries)
+			}
+		})
+	}
+
+	t.Run("parallel encoding", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+		index := bfs.NewInMemObject("path/to/file.idx.json")
+		defer index.Close()
+
+		w := feedx.NewWriter(t.Context(), obj, &feedx.WriterOptions{
+			SortBy: sortBy(t.TempDir()),
+			Index:  &feedx.IndexOptions{Remote: index, BlockSize: 64},
+		})
+		defer w.Discard()
+
+		cw := feedx.NewConcurrentWriter(w, &feedx.ConcurrentWriterOptions{ParallelEncoding: true})
+		for _, name := range []string{"c", "a", "b"} {
+			if err := cw.Encode(&testdata.MockMessage{Name: name}); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		r, err := feedx.NewReader(t.Context(), obj, nil)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		defer r.Close()
+
+		var names []string
+		for _, msg := range drainReader(t, r) {
+			names = append(names, msg.Name)
+		}
+		if exp := []string{"a", "b", "c"}; !slices.Equal(exp, names) {
+			t.Errorf("expected %v, got %v", exp, names)
+		}
+
+		idx, err := feedx.LoadIndex(t.Context(), index)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp, got := int64(3), idx.NumRecords(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if !idx.Sorted {
+			t.Error("expected index to be sorted")
+		}
+	})
+
+	t.Run("discard", func(t *testing.T) {
+		dir := t.TempDir()
+		obj := bfs.NewInMemObject("path/to/file.pb")
+		defer obj.Close()
+
+		w := feedx.NewWriter(t.Context(), obj, &feedx.WriterOptions{SortBy: sortBy(dir)})
+		for i := 0; i < 100; i++ {
+			if err := w.Encode(seed()); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		}
+		if err := w.Discard(); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		if entries, err := os.ReadDir(dir); err != nil {
+			t.Fatal("unexpected error", err)
+		} else if len(entries) != 0 {
+			t.Errorf("expected temporary files to be removed, got %v", entries)
+		}
+		if _, err := obj.Head(t.Context()); err != bfs.ErrNotFound {
+			t.Errorf("expected %v, got %v", bfs.ErrNotFound, err)
+		}
+	})
+
+	t.Run("unsorted", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.pb")
+		defer obj.Close()
+
+		write(t, obj, nil)
+
+		r, err := feedx.NewReader(t.Context(), obj, nil)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		defer r.Close()
+
+		if name, err := r.SortedBy(); err != nil {
+			t.Fatal("unexpected error", err)
+		} else if name != "" {
+			t.Errorf("expected no sort key, got %v", name)
+		}
+	})
+
+	t.Run("multiple remotes", func(t *testing.T) {
+		sorted := bfs.NewInMemObject("path/to/sorted.pb")
+		defer sorted.Close()
+		unsorted := bfs.NewInMemObject("path/to/unsorted.pb")
+		defer unsorted.Close()
+
+		write(t, sorted, &feedx.WriterOptions{SortBy: sortBy(t.TempDir())})
+		write(t, unsorted, nil)
+
+		for _, tc := range []struct {
+			remotes []*bfs.Object
+			exp     string
+		}{
+			{remotes: []*bfs.Object{sorted, sorted}, exp: "name"},
+			{remotes: []*bfs.Object{sorted, unsorted}, exp: ""},
+		} {
+			r := feedx.MultiReader(t.Context(), tc.remotes, nil)
+			if name, err := r.SortedBy(); err != nil {
+				t.Fatal("unexpected error", err)
+			} else if tc.exp != name {
+				t.Errorf("expected %q, got %q", tc.exp, name)
+			}
+			_ = r.Close()
+		}
+	})
+
+	t.Run("requires key", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.pb")
+		defer obj.Close()
+
+		w := feedx.NewWriter(t.Context(), obj, &feedx.WriterOptions{SortBy: &feedx.SortOptions{Name: "name"}})
+		defer w.Discard()
+
+		if err := w.Encode(seed()); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestExtSorter(t *testing.T) {
+	var keys, exp []string
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("%03d", (i*37)%100)
+		keys = append(keys, key)
+		exp = append(exp, key+"@"+strconv.Itoa(i))
+	}
+	slices.SortStableFunc(exp, func(a, b string) int { return strings.Compare(a[:3], b[:3]) })
+
+	for _, maxRuns := range []int{2, 4, 64} {
+		dir := t.TempDir()
+		got, numFiles, err := feedx.ExtSort(dir, 512, maxRuns, keys...)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if !slices.Equal(exp, got) {
+			t.Errorf("[%d] expected stable sort order, got %v", maxRuns, got)
+		}
+		if numFiles == 0 || numFiles >= maxRuns {
+			t.Errorf("[%d] expected 1..%d open runs, got %d", maxRuns, maxRuns-1, numFiles)
+		}
+		if entries, err := os.ReadDir(dir); err != nil {
+			t.Fatal("unexpected error", err)
+		} else if len(entries) != 0 {
+			t.Errorf("[%d] expected temporary files to be removed, got %d", maxRuns, len(entries))
+		}
+	}
+}
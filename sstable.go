@@ -0,0 +1,319 @@
+package feedx
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+// The sorted table file format stores unique keys in ascending order:
+//
+//	data:   entries, written by entryWriter
+//	index:  uvarint count, then count times uvarint length, key, uvarint offset
+//	bloom:  uvarint number of hash functions, followed by the filter bits
+//	footer: index offset, bloom offset, number of entries, version, magic
+//
+// The sparse index holds the key and the offset of every sstIndexInterval-th
+// entry. All footer values are fixed-size, big-endian uint64s.
+const (
+	sstMagic         = 0x66656564782d7374 // "feedx-st"
+	sstFooterSize    = 5 * 8
+	sstIndexInterval = 16
+	sstBloomBits     = 10 // bits per key
+)
+
+var errBadTable = errors.New("feedx: invalid table file")
+
+// sstWriter writes sorted table files. Entries must be added in ascending key
+// order, without duplicates.
+type sstWriter struct {
+	ew     *entryWriter
+	offset uint64
+
+	num   uint64
+	index []sstIndexEntry
+	bloom *bloomFilter
+}
+
+type sstIndexEntry struct {
+	key    string
+	offset uint64
+}
+
+func newSSTWriter(w io.Writer, expectedEntries int) *sstWriter {
+	return &sstWriter{
+		ew:    newEntryWriter(w),
+		bloom: newBloomFilter(expectedEntries),
+	}
+}
+
+// Add adds an entry.
+func (w *sstWriter) Add(e sortEntry) error {
+	if w.num%sstIndexInterval == 0 {
+		w.index = append(w.index, sstIndexEntry{key: e.key, offset: w.offset})
+	}
+	if err := w.ew.Write(e); err != nil {
+		return err
+	}
+
+	w.num++
+	w.offset += uint64(entrySize(e))
+	w.bloom.Add(e.key)
+	return nil
+}
+
+// Close writes index, bloom filter and footer.
+func (w *sstWriter) Close(version int64) error {
+	indexOffset := w.offset
+	buf := binary.AppendUvarint(nil, uint64(len(w.index)))
+	for _, ie := range w.index {
+		buf = binary.AppendUvarint(buf, uint64(len(ie.key)))
+		buf = append(buf, ie.key...)
+		buf = binary.AppendUvarint(buf, ie.offset)
+	}
+
+	bloomOffset := indexOffset + uint64(len(buf))
+	buf = binary.AppendUvarint(buf, uint64(w.bloom.k))
+	buf = append(buf, w.bloom.bits...)
+
+	buf = binary.BigEndian.AppendUint64(buf, indexOffset)
+	buf = binary.BigEndian.AppendUint64(buf, bloomOffset)
+	buf = binary.BigEndian.AppendUint64(buf, w.num)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(version))
+	buf = binary.BigEndian.AppendUint64(buf, sstMagic)
+
+	if _, err := w.ew.w.Write(buf); err != nil {
+		return err
+	}
+	return w.ew.Flush()
+}
+
+func entrySize(e sortEntry) int {
+	return uvarintSize(uint64(len(e.key))) + len(e.key) + uvarintSize(uint64(len(e.value))) + len(e.value)
+}
+
+func uvarintSize(n uint64) int {
+	var tmp [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(tmp[:], n)
+}
+
+// --------------------------------------------------------------------
+
+// sstReader provides random access to a sorted table file.
+type sstReader struct {
+	f *os.File
+
+	dataSize uint64
+	num      int64
+	version  int64
+	index    []sstIndexEntry
+	bloom    *bloomFilter
+}
+
+func openSSTReader(name string) (*sstReader, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := newSSTReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func newSSTReader(f *os.File) (*sstReader, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fi.Size()
+	if size < sstFooterSize {
+		return nil, errBadTable
+	}
+
+	footer := make([]byte, sstFooterSize)
+	if _, err := f.ReadAt(footer, size-sstFooterSize); err != nil {
+		return nil, err
+	}
+	indexOffset := binary.BigEndian.Uint64(footer[0:])
+	bloomOffset := binary.BigEndian.Uint64(footer[8:])
+	num := binary.BigEndian.Uint64(footer[16:])
+	version := binary.BigEndian.Uint64(footer[24:])
+	if binary.BigEndian.Uint64(footer[32:]) != sstMagic || indexOffset > bloomOffset || bloomOffset > uint64(size-sstFooterSize) {
+		return nil, errBadTable
+	}
+
+	meta := make([]byte, uint64(size-sstFooterSize)-indexOffset)
+	if _, err := f.ReadAt(meta, int64(indexOffset)); err != nil {
+		return nil, err
+	}
+
+	index, err := parseSSTIndex(meta[:bloomOffset-indexOffset])
+	if err != nil {
+		return nil, err
+	}
+
+	bloom := meta[bloomOffset-indexOffset:]
+	k, n := binary.Uvarint(bloom)
+	if n <= 0 {
+		return nil, errBadTable
+	}
+
+	return &sstReader{
+		f:        f,
+		dataSize: indexOffset,
+		num:      int64(num),
+		version:  int64(version),
+		index:    index,
+		bloom:    &bloomFilter{k: int(k), bits: bloom[n:]},
+	}, nil
+}
+
+func parseSSTIndex(data []byte) ([]sstIndexEntry, error) {
+	count, n := binary.Uvarint(data)
+	if n <= 0 || count > uint64(len(data)) {
+		return nil, errBadTable
+	}
+	data = data[n:]
+
+	index := make([]sstIndexEntry, 0, count)
+	for i := uint64(0); i < count; i++ {
+		klen, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < klen {
+			return nil, errBadTable
+		}
+		key := string(data[n : n+int(klen)])
+		data = data[n+int(klen):]
+
+		offset, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, errBadTable
+		}
+		data = data[n:]
+
+		index = append(index, sstIndexEntry{key: key, offset: offset})
+	}
+	return index, nil
+}
+
+// Get returns the value stored for key.
+func (r *sstReader) Get(key string) ([]byte, bool, error) {
+	if !r.bloom.Contains(key) {
+		return nil, false, nil
+	}
+
+	// find the last indexed entry with a key <= the search key
+	pos := sort.Search(len(r.index), func(i int) bool { return r.index[i].key > key }) - 1
+	if pos < 0 {
+		return nil, false, nil
+	}
+
+	end := r.dataSize
+	if pos+1 < len(r.index) {
+		end = r.index[pos+1].offset
+	}
+
+	start := r.index[pos].offset
+	er := newEntryReader(io.NewSectionReader(r.f, int64(start), int64(end-start)))
+	for {
+		e, err := er.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, false, nil
+		} else if err != nil {
+			return nil, false, err
+		}
+
+		if e.key == key {
+			return e.value, true, nil
+		} else if e.key > key {
+			return nil, false, nil
+		}
+	}
+}
+
+// Scan iterates over entries with keys in the range [start, end), in
+// ascending order. An empty end is unbounded.
+func (r *sstReader) Scan(start, end string, fn func(sortEntry) bool) error {
+	pos := max(sort.Search(len(r.index), func(i int) bool { return r.index[i].key > start })-1, 0)
+	if pos >= len(r.index) {
+		return nil
+	}
+
+	offset := r.index[pos].offset
+	er := newEntryReader(io.NewSectionReader(r.f, int64(offset), int64(r.dataSize-offset)))
+	for {
+		e, err := er.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if e.key < start {
+			continue
+		} else if end != "" && e.key >= end {
+			return nil
+		}
+		if !fn(e) {
+			return nil
+		}
+	}
+}
+
+// Close closes the file.
+func (r *sstReader) Close() error {
+	return r.f.Close()
+}
+
+// --------------------------------------------------------------------
+
+// bloomFilter is a simple bloom filter, using double hashing.
+type bloomFilter struct {
+	k    int
+	bits []byte
+}
+
+func newBloomFilter(n int) *bloomFilter {
+	nbits := max(n*sstBloomBits, 64)
+	k := max(int(math.Round(sstBloomBits*math.Ln2)), 1)
+	return &bloomFilter{k: k, bits: make([]byte, (nbits+7)/8)}
+}
+
+func (b *bloomFilter) Add(key string) {
+	h1, h2 := bloomHash(key)
+	m := uint64(len(b.bits) * 8)
+	for i := 0; i < b.k; i++ {
+		bit := (h1 + uint64(i)*h2) % m
+		b.bits[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+func (b *bloomFilter) Contains(key string) bool {
+	if len(b.bits) == 0 {
+		return false
+	}
+
+	h1, h2 := bloomHash(key)
+	m := uint64(len(b.bits) * 8)
+	for i := 0; i < b.k; i++ {
+		bit := (h1 + uint64(i)*h2) % m
+		if b.bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func bloomHash(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	sum := h.Sum64()
+	return sum, sum>>33 | 1
+}
@@ -0,0 +1,178 @@
+package feedx_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bsm/feedx"
+)
+
+func TestSSTable(t *testing.T) {
+	// even keys from 0000 to 0098, spanning several index intervals
+	var keys []string
+	for i := 0; i < 50; i++ {
+		keys = append(keys, fmt.Sprintf("%04d", i*2))
+	}
+
+	name := filepath.Join(t.TempDir(), "table.sst")
+	if err := feedx.WriteSSTable(name, 101, keys...); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+
+	r, err := feedx.OpenSSTable(name)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	defer r.Close()
+
+	if num, version := feedx.SSTableInfo(r); num != 50 || version != 101 {
+		t.Errorf("expected 50 entries of version 101, got %v, %v", num, version)
+	}
+
+	t.Run("get", func(t *testing.T) {
+		// all keys, including those at and around index boundaries
+		for _, key := range keys {
+			if val, ok, err := r.Get(key); err != nil {
+				t.Fatal("unexpected error", err)
+			} else if !ok || string(val) != "v"+key {
+				t.Errorf("expected v%s, got %q, %v", key, val, ok)
+			}
+		}
+
+		// missing keys, before, between and after the stored keys
+		for _, key := range []string{"", "000", "0001", "0031", "0032x", "0033", "0099", "1000"} {
+			if _, ok, err := r.Get(key); err != nil {
+				t.Fatal("unexpected error", err)
+			} else if ok {
+				t.Errorf("expected no value for %q", key)
+			}
+		}
+	})
+
+	t.Run("index boundaries", func(t *testing.T) {
+		for _, i := range []int{feedx.SSTIndexInterval - 1, feedx.SSTIndexInterval, feedx.SSTIndexInterval + 1, 2 * feedx.SSTIndexInterval, 3 * feedx.SSTIndexInterval} {
+			key := keys[i]
+			if _, ok, err := r.Get(key); err != nil || !ok {
+				t.Errorf("expected %s to be found, got %v, %v", key, ok, err)
+			}
+			if got, err := feedx.ScanSSTable(r, key, keys[i+1]); err != nil {
+				t.Fatal("unexpected error", err)
+			} else if exp := []string{key}; !reflect.DeepEqual(exp, got) {
+				t.Errorf("expected %v, got %v", exp, got)
+			}
+		}
+	})
+
+	t.Run("scan", func(t *testing.T) {
+		for _, tc := range []struct {
+			start, end string
+			exp        []string
+		}{
+			{"", "0004", []string{"0000", "0002"}},
+			{"0", "0003", []string{"0000", "0002"}},
+			{"0029", "0035", []string{"0030", "0032", "0034"}},
+			{"0096", "", []string{"0096", "0098"}},
+			{"0097", "x", []string{"0098"}},
+			{"0099", "", nil},
+			{"0010", "0010", nil},
+		} {
+			if got, err := feedx.ScanSSTable(r, tc.start, tc.end); err != nil {
+				t.Fatal("unexpected error", err)
+			} else if !reflect.DeepEqual(tc.exp, got) {
+				t.Errorf("[%q, %q) expected %v, got %v", tc.start, tc.end, tc.exp, got)
+			}
+		}
+
+		if got, err := feedx.ScanSSTable(r, "", ""); err != nil {
+			t.Fatal("unexpected error", err)
+		} else if !reflect.DeepEqual(keys, got) {
+			t.Errorf("expected %v, got %v", keys, got)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		name := filepath.Join(t.TempDir(), "empty.sst")
+		if err := feedx.WriteSSTable(name, 0); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		r, err := feedx.OpenSSTable(name)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		defer r.Close()
+
+		if _, ok, err := r.Get("0000"); err != nil || ok {
+			t.Errorf("expected no value, got %v, %v", ok, err)
+		}
+		if got, err := feedx.ScanSSTable(r, "", ""); err != nil || len(got) != 0 {
+			t.Errorf("expected no keys, got %v, %v", got, err)
+		}
+	})
+
+	t.Run("corrupt", func(t *testing.T) {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		footer := len(data) - 40
+		for _, tc := range []struct {
+			name    string
+			corrupt func([]byte) []byte
+		}{
+			{"truncated", func(b []byte) []byte { return b[:30] }},
+			{"missing footer", func(b []byte) []byte { return b[:footer] }},
+			{"bad magic", func(b []byte) []byte { b[len(b)-1] ^= 0xff; return b }},
+			{"index after bloom", func(b []byte) []byte { b[footer+7] = 0xff; return b }},
+			{"bloom out of bounds", func(b []byte) []byte { b[footer+8] = 0xff; return b }},
+			{"bad index", func(b []byte) []byte {
+				idx := footer - 1 // points the index at the last byte before the footer
+				for i := 0; i < 8; i++ {
+					b[footer+i], b[footer+8+i] = byte(idx>>(56-8*i)), byte(idx>>(56-8*i))
+				}
+				return b
+			}},
+		} {
+			name := filepath.Join(t.TempDir(), "corrupt.sst")
+			if err := os.WriteFile(name, tc.corrupt(append([]byte(nil), data...)), 0o644); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			if _, err := feedx.OpenSSTable(name); !errors.Is(err, feedx.ErrBadTable) {
+				t.Errorf("[%s] expected %v, got %v", tc.name, feedx.ErrBadTable, err)
+			}
+		}
+	})
+}
+
+func TestBloomFilter(t *testing.T) {
+	var keys []string
+	for i := 0; i < 1000; i++ {
+		keys = append(keys, fmt.Sprintf("key-%d", i))
+	}
+	contains := feedx.NewBloomFilter(keys...)
+
+	for _, key := range keys {
+		if !contains(key) {
+			t.Fatalf("expected %s to be contained", key)
+		}
+	}
+
+	var falsePositives int
+	for i := 0; i < 10000; i++ {
+		if contains(fmt.Sprintf("other-%d", i)) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 300 {
+		t.Errorf("expected a false positive rate below 3%%, got %d of 10000", falsePositives)
+	}
+
+	if feedx.NewBloomFilter()("key-1") {
+		t.Error("expected empty filter not to contain keys")
+	}
+}
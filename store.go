@@ -0,0 +1,291 @@
+package feedx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// StoreOptions configure a Store.
+type StoreOptions[V any] struct {
+	// Path is the path of the local table file. Temporary files are created
+	// in the same directory.
+	// Required.
+	Path string
+
+	// Key extracts the key of a record.
+	// Required.
+	Key func(V) string
+
+	// MaxMemory limits the approximate number of bytes buffered in memory
+	// while sorting records. Larger feeds are sorted externally, using
+	// temporary files.
+	// Default: 64 MiB
+	MaxMemory int
+
+	// ReaderOptions configure the reader.
+	// Default: nil
+	ReaderOptions *ReaderOptions
+}
+
+// Store materializes a feed into a local, on-disk table of records sorted by
+// key, for feeds which are too big to be held in memory. On every sync,
+// records of a changed feed are written to a new table file, which replaces
+// the current one atomically. If multiple records share a key, the last one
+// wins.
+//
+// Table files contain a sparse index and a bloom filter, which are kept in
+// memory. Records are stored in the same encoding as used by
+// ConsumeChanges. Stores are safe for concurrent use.
+type Store[V any] struct {
+	csm    Consumer
+	opt    StoreOptions[V]
+	codec  snapshotCodec
+	decode func(valueDecoder) (V, error)
+
+	mu  sync.RWMutex
+	cur *storeTable
+}
+
+// storeTable is a reference counted table, so it can be replaced while
+// readers are still using it.
+type storeTable struct {
+	*sstReader
+	refs atomic.Int64
+}
+
+func newStoreTable(r *sstReader) *storeTable {
+	t := &storeTable{sstReader: r}
+	t.refs.Store(1)
+	return t
+}
+
+func (t *storeTable) release() error {
+	if t.refs.Add(-1) == 0 {
+		return t.Close()
+	}
+	return nil
+}
+
+// OpenStore opens a store, backed by a consumer. If a table file exists at
+// the configured path, it is served until the first successful Sync.
+func OpenStore[V any](csm Consumer, opt *StoreOptions[V]) (*Store[V], error) {
+	if opt == nil || opt.Path == "" {
+		return nil, errors.New("feedx: store requires a path")
+	}
+	if opt.Key == nil {
+		return nil, errors.New("feedx: store requires a key function")
+	}
+
+	var msgType protoreflect.MessageType
+	if opt.ReaderOptions != nil {
+		msgType = opt.ReaderOptions.MessageType
+	}
+
+	s := &Store[V]{
+		csm:    csm,
+		opt:    *opt,
+		codec:  snapshotCodec{msgType: msgType},
+		decode: newRecordDecoder[V](),
+	}
+
+	r, err := openSSTReader(opt.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	s.cur = newStoreTable(r)
+	return s, nil
+}
+
+// Sync materializes the feed if it has changed since the previous sync.
+func (s *Store[V]) Sync(ctx context.Context) (*Status, error) {
+	return s.csm.Consume(ctx, s.opt.ReaderOptions, s.consume)
+}
+
+// Get returns the value stored for a key.
+func (s *Store[V]) Get(key string) (V, bool, error) {
+	var zero V
+
+	t := s.acquire()
+	if t == nil {
+		return zero, false, nil
+	}
+	defer t.release()
+
+	data, ok, err := t.Get(key)
+	if err != nil || !ok {
+		return zero, false, err
+	}
+
+	v, err := s.decode(s.codec.Decoder(data))
+	if err != nil {
+		return zero, false, err
+	}
+	return v, true, nil
+}
+
+// Range calls fn sequentially for each key and value with a key in the range
+// [start, end), in ascending key order, until fn returns false. An empty end
+// is unbounded. It iterates over a consistent snapshot, concurrent syncs do
+// not affect an ongoing iteration.
+func (s *Store[V]) Range(start, end string, fn func(string, V) bool) error {
+	t := s.acquire()
+	if t == nil {
+		return nil
+	}
+	defer t.release()
+
+	var err error
+	if e := t.Scan(start, end, func(e sortEntry) bool {
+		var v V
+		if v, err = s.decode(s.codec.Decoder(e.value)); err != nil {
+			return false
+		}
+		return fn(e.key, v)
+	}); e != nil {
+		return e
+	}
+	return err
+}
+
+// Len returns the number of entries.
+func (s *Store[V]) Len() int64 {
+	t := s.acquire()
+	if t == nil {
+		return 0
+	}
+	defer t.release()
+
+	return t.num
+}
+
+// Version returns the version of the materialized data.
+func (s *Store[V]) Version() int64 {
+	t := s.acquire()
+	if t == nil {
+		return 0
+	}
+	defer t.release()
+
+	return t.version
+}
+
+// Close closes the store and the underlying consumer.
+func (s *Store[V]) Close() (err error) {
+	s.mu.Lock()
+	t := s.cur
+	s.cur = nil
+	s.mu.Unlock()
+
+	if t != nil {
+		err = t.release()
+	}
+	if e := s.csm.Close(); e != nil {
+		err = errors.Join(err, e)
+	}
+	return
+}
+
+func (s *Store[V]) acquire() *storeTable {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.cur != nil {
+		s.cur.refs.Add(1)
+	}
+	return s.cur
+}
+
+func (s *Store[V]) consume(r *Reader) error {
+	version, err := r.consumedVersion()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.opt.Path)
+	sorter := newExtSorter(dir, s.opt.MaxMemory)
+	defer func() { _ = sorter.Close() }()
+
+	var num int
+	for v, err := range Records[V](r) {
+		if err != nil {
+			return err
+		}
+
+		data, err := s.codec.Encode(v)
+		if err != nil {
+			return err
+		}
+		if err := sorter.Add(s.opt.Key(v), data); err != nil {
+			return err
+		}
+		num++
+	}
+
+	sorted, err := sorter.Sort()
+	if err != nil {
+		return err
+	}
+	if err := s.writeTable(dir, &lastEntryIterator{it: sorted}, num, version); err != nil {
+		return err
+	}
+
+	next, err := openSSTReader(s.opt.Path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	prev := s.cur
+	s.cur = newStoreTable(next)
+	s.mu.Unlock()
+
+	if prev != nil {
+		return prev.release()
+	}
+	return nil
+}
+
+func (s *Store[V]) writeTable(dir string, it entryIterator, num int, version int64) (err error) {
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.opt.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	w := newSSTWriter(tmp, num)
+	for {
+		e, ok, err := nextEntry(it)
+		if err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+
+		if err := w.Add(e); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(version); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.opt.Path)
+}
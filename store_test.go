@@ -0,0 +1,187 @@
+package feedx_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+	"github.com/bsm/feedx/internal/testdata"
+)
+
+func TestStore(t *testing.T) {
+	// produce writes a feed version with records named by their index.
+	produce := func(t *testing.T, obj *bfs.Object, version int64, n int, height uint32) {
+		t.Helper()
+
+		w := feedx.NewWriter(t.Context(), obj, &feedx.WriterOptions{Version: version})
+		defer w.Discard()
+
+		for i := n - 1; i >= 0; i-- {
+			if err := w.Encode(&testdata.MockMessage{Name: fmt.Sprintf("%04d", i), Height: height}); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+	}
+
+	keys := func(t *testing.T, s *feedx.Store[*testdata.MockMessage], start, end string) []string {
+		t.Helper()
+
+		var res []string
+		if err := s.Range(start, end, func(key string, msg *testdata.MockMessage) bool {
+			if key != msg.Name {
+				t.Fatalf("expected %v, got %v", key, msg.Name)
+			}
+			res = append(res, key)
+			return true
+		}); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		return res
+	}
+
+	obj := bfs.NewInMemObject("path/to/file.pb")
+	defer obj.Close()
+
+	opt := &feedx.StoreOptions[*testdata.MockMessage]{
+		Path:      filepath.Join(t.TempDir(), "store.sst"),
+		Key:       (*testdata.MockMessage).GetName,
+		MaxMemory: 1024,
+	}
+	s, err := feedx.OpenStore(feedx.NewConsumerForRemote(obj), opt)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	defer s.Close()
+
+	if exp, got := int64(0), s.Len(); exp != got {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+	if _, ok, err := s.Get("0001"); err != nil || ok {
+		t.Errorf("expected no value, got %v, %v", ok, err)
+	}
+
+	produce(t, obj, 101, 1000, 1)
+	if _, err := s.Sync(t.Context()); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if exp, got := int64(1000), s.Len(); exp != got {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+	if exp, got := int64(101), s.Version(); exp != got {
+		t.Errorf("expected %v, got %v", exp, got)
+	}
+
+	t.Run("get", func(t *testing.T) {
+		for _, key := range []string{"0000", "0015", "0016", "0017", "0500", "0999"} {
+			if msg, ok, err := s.Get(key); err != nil {
+				t.Fatal("unexpected error", err)
+			} else if !ok || msg.Name != key || msg.Height != 1 {
+				t.Errorf("expected %v, got %v", key, msg)
+			}
+		}
+		for _, key := range []string{"", "000", "00001", "1000", "x"} {
+			if _, ok, err := s.Get(key); err != nil {
+				t.Fatal("unexpected error", err)
+			} else if ok {
+				t.Errorf("expected no value for %q", key)
+			}
+		}
+	})
+
+	t.Run("range", func(t *testing.T) {
+		if exp, got := []string{"0014", "0015", "0016", "0017"}, keys(t, s, "0014", "0018"); !reflect.DeepEqual(exp, got) {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := []string{"0998", "0999"}, keys(t, s, "0998", ""); !reflect.DeepEqual(exp, got) {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := 1000, len(keys(t, s, "", "")); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if got := keys(t, s, "1", ""); len(got) != 0 {
+			t.Errorf("expected no keys, got %v", got)
+		}
+
+		var n int
+		if err := s.Range("", "", func(string, *testdata.MockMessage) bool {
+			n++
+			return n < 3
+		}); err != nil {
+			t.Fatal("unexpected error", err)
+		} else if exp, got := 3, n; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("switch over", func(t *testing.T) {
+		produce(t, obj, 102, 10, 2)
+
+		var heights []uint32
+		if err := s.Range("0008", "", func(key string, msg *testdata.MockMessage) bool {
+			if len(heights) == 0 {
+				if _, err := s.Sync(t.Context()); err != nil {
+					t.Fatal("unexpected error", err)
+				}
+			}
+			heights = append(heights, msg.Height)
+			return len(heights) < 3
+		}); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp := []uint32{1, 1, 1}; !reflect.DeepEqual(exp, heights) {
+			t.Errorf("expected %v, got %v", exp, heights)
+		}
+
+		if exp, got := int64(10), s.Len(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := int64(102), s.Version(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if msg, ok, err := s.Get("0009"); err != nil || !ok || msg.Height != 2 {
+			t.Errorf("expected updated value, got %v, %v, %v", msg, ok, err)
+		}
+		if _, ok, err := s.Get("0010"); err != nil || ok {
+			t.Errorf("expected no value, got %v, %v", ok, err)
+		}
+
+		if matches, err := filepath.Glob(filepath.Join(filepath.Dir(opt.Path), "*")); err != nil {
+			t.Fatal("unexpected error", err)
+		} else if exp := []string{opt.Path}; !reflect.DeepEqual(exp, matches) {
+			t.Errorf("expected %v, got %v", exp, matches)
+		}
+	})
+
+	t.Run("reopen", func(t *testing.T) {
+		s2, err := feedx.OpenStore(feedx.NewConsumerForRemote(obj), opt)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		defer s2.Close()
+
+		if exp, got := int64(10), s2.Len(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := int64(102), s2.Version(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := []string{"0008", "0009"}, keys(t, s2, "0008", ""); !reflect.DeepEqual(exp, got) {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("requires options", func(t *testing.T) {
+		if _, err := feedx.OpenStore[*testdata.MockMessage](feedx.NewConsumerForRemote(obj), nil); err == nil {
+			t.Error("expected error")
+		}
+		if _, err := feedx.OpenStore(feedx.NewConsumerForRemote(obj), &feedx.StoreOptions[*testdata.MockMessage]{Path: opt.Path}); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package feedx
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync/atomic"
+)
+
+// TableOptions configure a Table.
+type TableOptions[K comparable, V any] struct {
+	// Key extracts the key of a record.
+	// Required.
+	Key func(V) K
+
+	// Deleted reports whether a record is a tombstone, which removes its key
+	// from the table. Only useful with incremental feeds.
+	// Default: nil (records are never deleted)
+	Deleted func(V) bool
+
+	// ReaderOptions configure the reader.
+	// Default: nil
+	ReaderOptions *ReaderOptions
+}
+
+// Table is an in-memory materialized view of a feed. On every sync, records
+// of a changed feed are loaded into a new map which is swapped in
+// atomically, if multiple records share a key, the last one wins.
+//
+// For incremental feeds, only data files which have been added since the
+// previous sync are applied on top of a copy of the current map, as upserts
+// or deletes. Tables are safe for concurrent use.
+type Table[K comparable, V any] struct {
+	csm   Consumer
+	opt   TableOptions[K, V]
+	state atomic.Pointer[tableState[K, V]]
+}
+
+type tableState[K comparable, V any] struct {
+	data    map[K]V
+	files   []string
+	version int64
+}
+
+// NewTable inits a new table, backed by a consumer. The table is empty
+// until the first Sync.
+func NewTable[K comparable, V any](csm Consumer, opt *TableOptions[K, V]) (*Table[K, V], error) {
+	if opt == nil || opt.Key == nil {
+		return nil, errors.New("feedx: table requires a key function")
+	}
+
+	t := &Table[K, V]{csm: csm, opt: *opt}
+	t.state.Store(&tableState[K, V]{})
+	return t, nil
+}
+
+// Sync loads the feed if it has changed since the previous sync.
+func (t *Table[K, V]) Sync(ctx context.Context) (*Status, error) {
+	return t.csm.Consume(ctx, t.opt.ReaderOptions, t.consume)
+}
+
+// Get returns the value stored for a key.
+func (t *Table[K, V]) Get(key K) (V, bool) {
+	v, ok := t.state.Load().data[key]
+	return v, ok
+}
+
+// Len returns the number of entries.
+func (t *Table[K, V]) Len() int {
+	return len(t.state.Load().data)
+}
+
+// Range calls fn sequentially for each key and value, in no particular
+// order, until fn returns false. It iterates over a consistent snapshot,
+// concurrent syncs do not affect an ongoing iteration.
+func (t *Table[K, V]) Range(fn func(K, V) bool) {
+	for k, v := range t.state.Load().data {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+// Version returns the version of the loaded data.
+func (t *Table[K, V]) Version() int64 {
+	return t.state.Load().version
+}
+
+// Close closes the underlying consumer.
+func (t *Table[K, V]) Close() error {
+	return t.csm.Close()
+}
+
+func (t *Table[K, V]) consume(r *Reader) error {
+	version, err := r.consumedVersion()
+	if err != nil {
+		return err
+	}
+
+	files := make([]string, 0, len(r.remotes))
+	for _, remote := range r.remotes {
+		files = append(files, remote.Name())
+	}
+
+	prev := t.state.Load()
+	next := &tableState[K, V]{files: files, version: version}
+	if r.incremental && len(prev.files) != 0 && len(prev.files) <= len(files) && slices.Equal(prev.files, files[:len(prev.files)]) {
+		next.data = maps.Clone(prev.data)
+		r.skipRemotes(len(prev.files))
+	} else {
+		next.data = make(map[K]V, len(prev.data))
+	}
+
+	for v, err := range Records[V](r) {
+		if err != nil {
+			return err
+		}
+
+		key := t.opt.Key(v)
+		if t.opt.Deleted != nil && t.opt.Deleted(v) {
+			delete(next.data, key)
+		} else {
+			next.data[key] = v
+		}
+	}
+
+	t.state.Store(next)
+	return nil
+}
@@ -0,0 +1,231 @@
+package feedx_test
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+	"github.com/bsm/feedx/internal/testdata"
+)
+
+func TestTable(t *testing.T) {
+	opt := &feedx.TableOptions[string, *testdata.MockMessage]{
+		Key:     (*testdata.MockMessage).GetName,
+		Deleted: func(msg *testdata.MockMessage) bool { return msg.Height == 0 },
+	}
+
+	heights := func(tbl *feedx.Table[string, *testdata.MockMessage]) map[string]uint32 {
+		res := make(map[string]uint32, tbl.Len())
+		tbl.Range(func(key string, msg *testdata.MockMessage) bool {
+			res[key] = msg.Height
+			return true
+		})
+		return res
+	}
+
+	encodeAll := func(w *feedx.Writer, msgs ...*testdata.MockMessage) error {
+		for _, msg := range msgs {
+			if err := w.Encode(msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	t.Run("plain", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		pcr := feedx.NewProducerForRemote(obj)
+		defer pcr.Close()
+
+		tbl, err := feedx.NewTable(feedx.NewConsumerForRemote(obj), opt)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		defer tbl.Close()
+
+		if exp, got := 0, tbl.Len(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if _, ok := tbl.Get("a"); ok {
+			t.Error("expected no value")
+		}
+
+		if _, err := pcr.Produce(t.Context(), 101, nil, func(w *feedx.Writer) error {
+			return encodeAll(w, &testdata.MockMessage{Name: "a", Height: 1}, &testdata.MockMessage{Name: "b", Height: 2}, &testdata.MockMessage{Name: "a", Height: 3})
+		}); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if _, err := tbl.Sync(t.Context()); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp, got := int64(101), tbl.Version(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := map[string]uint32{"a": 3, "b": 2}, heights(tbl); !maps.Equal(exp, got) {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if msg, ok := tbl.Get("b"); !ok || msg.Height != 2 {
+			t.Errorf("expected b, got %v", msg)
+		}
+
+		if _, err := pcr.Produce(t.Context(), 102, nil, func(w *feedx.Writer) error {
+			return encodeAll(w, &testdata.MockMessage{Name: "c", Height: 4})
+		}); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if _, err := tbl.Sync(t.Context()); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp, got := int64(102), tbl.Version(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := map[string]uint32{"c": 4}, heights(tbl); !maps.Equal(exp, got) {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+
+		if status, err := tbl.Sync(t.Context()); err != nil {
+			t.Fatal("unexpected error", err)
+		} else if !status.Skipped {
+			t.Error("expected sync to be skipped")
+		}
+	})
+
+	t.Run("incremental", func(t *testing.T) {
+		bucket := bfs.NewInMem()
+		defer bucket.Close()
+
+		pcr := feedx.NewIncrementalProducerForBucket(bucket)
+		defer pcr.Close()
+
+		tbl, err := feedx.NewTable(feedx.NewIncrementalConsumerForBucket(bucket), opt)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		defer tbl.Close()
+
+		produce := func(version int64, msgs ...*testdata.MockMessage) {
+			t.Helper()
+
+			if _, err := pcr.Produce(t.Context(), version, nil, func(_ int64) feedx.ProduceFunc {
+				return func(w *feedx.Writer) error { return encodeAll(w, msgs...) }
+			}); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		}
+
+		produce(101, &testdata.MockMessage{Name: "a", Height: 1}, &testdata.MockMessage{Name: "b", Height: 2})
+		if status, err := tbl.Sync(t.Context()); err != nil {
+			t.Fatal("unexpected error", err)
+		} else if exp, got := int64(2), status.NumItems; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		} else if exp, got := []string{"manifest.json", "data-0-101.json"}, status.Objects; !slices.Equal(exp, got) {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+
+		produce(102, &testdata.MockMessage{Name: "b"}, &testdata.MockMessage{Name: "c", Height: 3})
+		produce(103, &testdata.MockMessage{Name: "a", Height: 4})
+		if status, err := tbl.Sync(t.Context()); err != nil {
+			t.Fatal("unexpected error", err)
+		} else if exp, got := int64(3), status.NumItems; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		} else if exp, got := []string{"manifest.json", "data-0-102.json", "data-0-103.json"}, status.Objects; !slices.Equal(exp, got) {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := int64(103), tbl.Version(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := map[string]uint32{"a": 4, "c": 3}, heights(tbl); !maps.Equal(exp, got) {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+
+		// reloads fully when files are rewritten
+		mft, err := feedx.LoadManifest(t.Context(), bfs.NewObjectFromBucket(bucket, "manifest.json"))
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if err := writeManifest(t, bucket, 104, mft.Files[1:]...); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if status, err := tbl.Sync(t.Context()); err != nil {
+			t.Fatal("unexpected error", err)
+		} else if exp, got := int64(3), status.NumItems; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := map[string]uint32{"a": 4, "c": 3}, heights(tbl); !maps.Equal(exp, got) {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := int64(104), tbl.Version(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("requires options", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		if _, err := feedx.NewTable[string, *testdata.MockMessage](feedx.NewConsumerForRemote(obj), nil); err == nil {
+			t.Error("expected error")
+		}
+		if _, err := feedx.NewTable(feedx.NewConsumerForRemote(obj), &feedx.TableOptions[string, *testdata.MockMessage]{}); err == nil {
+			t.Error("expected error")
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		tbl, err := feedx.NewTable(feedx.NewConsumerForRemote(obj), opt)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		defer tbl.Close()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					tbl.Get("a")
+					tbl.Len()
+					tbl.Range(func(string, *testdata.MockMessage) bool { return true })
+				}
+			}()
+		}
+
+		for version := int64(101); version < 111; version++ {
+			if err := writeN(obj, 3, version); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			if _, err := tbl.Sync(t.Context()); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		}
+		wg.Wait()
+
+		if exp, got := int64(110), tbl.Version(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+}
+
+func writeManifest(t *testing.T, bucket bfs.Bucket, version int64, files ...string) error {
+	t.Helper()
+
+	obj := bfs.NewObjectFromBucket(bucket, "manifest.json")
+	defer obj.Close()
+
+	w := feedx.NewWriter(t.Context(), obj, &feedx.WriterOptions{Version: version})
+	defer w.Discard()
+
+	if err := w.Encode(&feedx.Manifest{Version: version, Generation: 1, Files: files}); err != nil {
+		return err
+	}
+	return w.Commit()
+}
@@ -0,0 +1,79 @@
+package feedx
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Tracer instances create spans for traced operations.
+// Implementations must be safe for concurrent use.
+type Tracer interface {
+	// Start creates a span and a context containing the newly-created span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span represents a single traced operation.
+type Span interface {
+	// SetAttributes sets attributes on the span.
+	SetAttributes(attrs ...slog.Attr)
+	// RecordError records an error and marks the span as failed.
+	RecordError(err error)
+	// End completes the span.
+	End()
+}
+
+type tracerKey struct{}
+
+// ContextWithTracer returns a copy of ctx which carries the tracer. Spans
+// are created for all operations performed with the returned context and
+// nested within the span of ctx, if present.
+func ContextWithTracer(ctx context.Context, tracer Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, tracer)
+}
+
+func startSpan(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, Span) {
+	tracer, ok := ctx.Value(tracerKey{}).(Tracer)
+	if !ok {
+		return ctx, noopSpan{}
+	}
+
+	ctx, span := tracer.Start(ctx, name)
+	if len(attrs) != 0 {
+		span.SetAttributes(attrs...)
+	}
+	return ctx, span
+}
+
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+func endSyncSpan(span Span, status *Status, err error) {
+	if status != nil {
+		span.SetAttributes(
+			slog.Bool("feedx.skipped", status.Skipped),
+			slog.Int64("feedx.remote_version", status.RemoteVersion),
+			slog.Int64("feedx.items", status.NumItems),
+			slog.Int64("feedx.bytes", status.NumBytes),
+			slog.Int("feedx.retries", status.Retries),
+		)
+	}
+	endSpan(span, err)
+}
+
+// traceCallback wraps a user callback in a span.
+func traceCallback(ctx context.Context, fn func() error) error {
+	_, span := startSpan(ctx, "feedx.callback")
+	err := fn()
+	endSpan(span, err)
+	return err
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...slog.Attr) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
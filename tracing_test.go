@@ -0,0 +1,178 @@
+package feedx_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+)
+
+func TestTracer(t *testing.T) {
+	t.Run("producer job", func(t *testing.T) {
+		bucket := bfs.NewInMem()
+		defer bucket.Close()
+
+		pcr := feedx.NewIncrementalProducerForBucket(bucket)
+		defer pcr.Close()
+
+		tracer := new(mockTracer)
+		if _, err := feedx.NewJob().
+			WithTracer(tracer).
+			WithVersionCheck(func(_ context.Context) (int64, error) { return 101, nil }).
+			ProduceIncrementallyWith(t.Context(), pcr, func(_ int64) feedx.ProduceFunc {
+				return func(w *feedx.Writer) error { return w.Encode(seed()) }
+			}); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		if exp, got := []string{
+			"feedx.job",
+			"feedx.job > feedx.version_check",
+			"feedx.job > feedx.produce",
+			"feedx.job > feedx.produce > feedx.load_manifest",
+			"feedx.job > feedx.produce > feedx.load_manifest > feedx.read",
+			"feedx.job > feedx.produce > feedx.callback",
+			"feedx.job > feedx.produce > feedx.write",
+			"feedx.job > feedx.produce > feedx.write",
+		}, tracer.Paths(); !reflect.DeepEqual(exp, got) {
+			t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(exp, "\n"), strings.Join(got, "\n"))
+		}
+
+		span := tracer.Find("feedx.produce")
+		if exp, got := "manifest.json", span.attrs["feedx.feed"]; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := "1", span.attrs["feedx.items"]; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if !span.ended || span.err != nil {
+			t.Errorf("expected span to have ended successfully, got %+v", span)
+		}
+		if exp, got := "data-0-101.json", tracer.Find("feedx.write").attrs["feedx.object"]; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("consumer errors", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		if err := writeN(obj, 2, 101); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		csm := feedx.NewConsumerForRemote(obj)
+		defer csm.Close()
+
+		tracer := new(mockTracer)
+		exp := errors.New("failed!")
+		if _, err := csm.Consume(feedx.ContextWithTracer(t.Context(), tracer), nil, func(r *feedx.Reader) error {
+			if _, err := readMessages(r); err != nil {
+				return err
+			}
+			return exp
+		}); err != exp {
+			t.Fatalf("expected %v, got %v", exp, err)
+		}
+
+		if exp, got := []string{
+			"feedx.consume",
+			"feedx.consume > feedx.fetch_remote_version",
+			"feedx.consume > feedx.callback",
+			"feedx.consume > feedx.read",
+		}, tracer.Paths(); !reflect.DeepEqual(exp, got) {
+			t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(exp, "\n"), strings.Join(got, "\n"))
+		}
+
+		for _, name := range []string{"feedx.consume", "feedx.callback"} {
+			if span := tracer.Find(name); span.err != exp || !span.ended {
+				t.Errorf("expected %s to have failed with %v, got %+v", name, exp, span)
+			}
+		}
+		if span := tracer.Find("feedx.read"); span.err != nil || !span.ended {
+			t.Errorf("expected span to have ended successfully, got %+v", span)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		csm := feedx.NewConsumerForRemote(obj)
+		defer csm.Close()
+
+		if _, err := feedx.NewJob().ConsumeWith(t.Context(), csm, func(r *feedx.Reader) error { return nil }); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+	})
+}
+
+type mockTracer struct {
+	mu    sync.Mutex
+	spans []*mockSpan
+}
+
+func (t *mockTracer) Start(ctx context.Context, name string) (context.Context, feedx.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	span := &mockSpan{name: name, attrs: make(map[string]string)}
+	span.parent, _ = ctx.Value(mockSpanKey{}).(*mockSpan)
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, mockSpanKey{}, span), span
+}
+
+// Paths returns the paths of all spans, in order of creation.
+func (t *mockTracer) Paths() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	paths := make([]string, 0, len(t.spans))
+	for _, span := range t.spans {
+		path := span.name
+		for p := span.parent; p != nil; p = p.parent {
+			path = p.name + " > " + path
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// Find returns the first span with the given name.
+func (t *mockTracer) Find(name string) *mockSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, span := range t.spans {
+		if span.name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+type mockSpanKey struct{}
+
+type mockSpan struct {
+	name   string
+	parent *mockSpan
+	attrs  map[string]string
+	err    error
+	ended  bool
+}
+
+func (s *mockSpan) SetAttributes(attrs ...slog.Attr) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = fmt.Sprint(attr.Value)
+	}
+}
+
+func (s *mockSpan) RecordError(err error) { s.err = err }
+func (s *mockSpan) End()                  { s.ended = true }
@@ -0,0 +1,216 @@
+package feedx
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/bsm/feedx/internal/recordjson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ErrInvalidRecord is wrapped by errors of records which failed validation.
+// Writes aborted due to invalid records are never retried.
+var ErrInvalidRecord = errors.New("feedx: invalid record")
+
+// InvalidAction specifies how writers handle records which fail validation.
+// Invalid records are never written but always counted.
+type InvalidAction int
+
+// Supported invalid record actions.
+const (
+	// RejectInvalid returns validation errors from Encode and lets the
+	// caller decide whether to continue.
+	RejectInvalid InvalidAction = iota
+	// SkipInvalid silently skips invalid records.
+	SkipInvalid
+	// AbortInvalid fails the writer on the first invalid record. Subsequent
+	// calls to Encode and Commit return the validation error.
+	AbortInvalid
+)
+
+// ValidateFunc validates a record before it is encoded.
+type ValidateFunc func(v interface{}) error
+
+// ValidateAll combines validators, returning the first error.
+func ValidateAll(fns ...ValidateFunc) ValidateFunc {
+	return func(v interface{}) error {
+		for _, fn := range fns {
+			if err := fn(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// ValidateProtoRequired validates that all required fields of protobuf
+// messages, including nested messages, are set. Other values are ignored.
+func ValidateProtoRequired(v interface{}) error {
+	if msg, ok := v.(proto.Message); ok {
+		return proto.CheckInitialized(msg)
+	}
+	return nil
+}
+
+// ValidateJSONSchema validates records against a JSON Schema, as returned by
+// JSONSchema. Records are validated by their JSON representation, protobuf
+// messages are converted using protojson, but retain 64-bit integers as
+// numbers. The same subset of JSON Schema keywords which is supported by
+// compatibility checks is validated: type, required, properties and items.
+func ValidateJSONSchema(schema Schema) ValidateFunc {
+	js, ok := schema.(*jsonSchema)
+	if !ok {
+		return func(interface{}) error {
+			return fmt.Errorf("feedx: %s is not a JSON schema", schema.Type())
+		}
+	}
+
+	return func(v interface{}) error {
+		data, err := recordjson.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		generic, err := recordjson.Unmarshal(data)
+		if err != nil {
+			return err
+		}
+		if msg, ok := v.(proto.Message); ok {
+			generic = normProtoJSON(msg.ProtoReflect().Descriptor(), generic)
+		}
+		return js.root.validate(generic, "$")
+	}
+}
+
+func (n *jsonSchemaNode) validate(v interface{}, path string) error {
+	if n == nil {
+		return nil
+	}
+
+	if len(n.Type) != 0 {
+		typ := jsonTypeOf(v)
+		if !slices.Contains(n.Type, typ) && (typ != "integer" || !slices.Contains(n.Type, "number")) {
+			return fmt.Errorf("%s must be of type %v, got %s", path, n.Type, typ)
+		}
+	}
+
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for _, name := range n.Required {
+			if _, ok := vv[name]; !ok {
+				return fmt.Errorf("%s.%s is required", path, name)
+			}
+		}
+		for name, prop := range n.Properties {
+			if val, ok := vv[name]; ok {
+				if err := prop.validate(val, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		for i, val := range vv {
+			if err := n.Items.validate(val, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// normProtoJSON converts the 64-bit integers of a protojson encoded message
+// of type md, which protojson encodes as strings, back into numbers.
+func normProtoJSON(md protoreflect.MessageDescriptor, v interface{}) interface{} {
+	switch md.FullName() {
+	case "google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		return parseProtoInt(v)
+	case "google.protobuf.Any", "google.protobuf.Duration", "google.protobuf.FieldMask",
+		"google.protobuf.ListValue", "google.protobuf.Struct", "google.protobuf.Timestamp",
+		"google.protobuf.Value":
+		return v // well-known types with custom representations
+	}
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+
+	fields := md.Fields()
+	for key, val := range m {
+		fd := fields.ByName(protoreflect.Name(key))
+		if fd == nil {
+			continue
+		}
+
+		switch {
+		case fd.IsMap():
+			if vv, ok := val.(map[string]interface{}); ok {
+				for k, x := range vv {
+					vv[k] = normProtoValue(fd.MapValue(), x)
+				}
+			}
+		case fd.IsList():
+			if vv, ok := val.([]interface{}); ok {
+				for i, x := range vv {
+					vv[i] = normProtoValue(fd, x)
+				}
+			}
+		default:
+			m[key] = normProtoValue(fd, val)
+		}
+	}
+	return m
+}
+
+func normProtoValue(fd protoreflect.FieldDescriptor, v interface{}) interface{} {
+	switch fd.Kind() {
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return parseProtoInt(v)
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return normProtoJSON(fd.Message(), v)
+	}
+	return v
+}
+
+func parseProtoInt(v interface{}) interface{} {
+	s, ok := v.(string)
+	if !ok {
+		return v
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return float64(n)
+	}
+	return v
+}
+
+func jsonTypeOf(v interface{}) string {
+	switch vv := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case int64:
+		return "integer"
+	case float64:
+		if vv == math.Trunc(vv) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
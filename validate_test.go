@@ -0,0 +1,268 @@
+package feedx_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/bsm/bfs"
+	"github.com/bsm/feedx"
+	"github.com/bsm/feedx/internal/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestWriter_Validate(t *testing.T) {
+	errShort := errors.New("name too short")
+	validate := func(v interface{}) error {
+		if msg := v.(*testdata.MockMessage); len(msg.Name) < 3 {
+			return errShort
+		}
+		return nil
+	}
+	records := []*testdata.MockMessage{seed(), {Name: "Al"}, seed()}
+
+	encodeAll := func(w *feedx.Writer) (errs []error) {
+		for _, rec := range records {
+			errs = append(errs, w.Encode(rec))
+		}
+		return
+	}
+
+	t.Run("rejects", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		w := feedx.NewWriter(t.Context(), obj, &feedx.WriterOptions{Validate: validate})
+		defer w.Discard()
+
+		errs := encodeAll(w)
+		if errs[0] != nil || errs[2] != nil {
+			t.Fatalf("unexpected errors %v", errs)
+		}
+		if err := errs[1]; !errors.Is(err, feedx.ErrInvalidRecord) || !errors.Is(err, errShort) {
+			t.Errorf("expected validation error, got %v", err)
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		if exp, got := int64(2), w.NumWritten(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := int64(1), w.NumInvalid(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("skips", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		w := feedx.NewWriter(t.Context(), obj, &feedx.WriterOptions{Validate: validate, OnInvalid: feedx.SkipInvalid})
+		defer w.Discard()
+
+		if errs := encodeAll(w); !reflect.DeepEqual([]error{nil, nil, nil}, errs) {
+			t.Fatalf("unexpected errors %v", errs)
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		if exp, got := int64(2), w.NumWritten(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := int64(1), w.NumInvalid(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("aborts", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		w := feedx.NewWriter(t.Context(), obj, &feedx.WriterOptions{Validate: validate, OnInvalid: feedx.AbortInvalid})
+		defer w.Discard()
+
+		errs := encodeAll(w)
+		if errs[0] != nil || !errors.Is(errs[1], errShort) || !errors.Is(errs[2], errShort) {
+			t.Fatalf("unexpected errors %v", errs)
+		}
+		if err := w.Commit(); !errors.Is(err, feedx.ErrInvalidRecord) {
+			t.Fatalf("expected %v, got %v", feedx.ErrInvalidRecord, err)
+		}
+		if _, err := obj.Head(t.Context()); err != bfs.ErrNotFound {
+			t.Errorf("expected %v, got %v", bfs.ErrNotFound, err)
+		}
+	})
+
+	t.Run("parallel encoding", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		w := feedx.NewWriter(t.Context(), obj, &feedx.WriterOptions{Validate: validate, OnInvalid: feedx.SkipInvalid})
+		defer w.Discard()
+
+		cw := feedx.NewConcurrentWriter(w, &feedx.ConcurrentWriterOptions{ParallelEncoding: true})
+		for _, rec := range records {
+			if err := cw.Encode(rec); err != nil {
+				t.Fatal("unexpected error", err)
+			}
+		}
+		if err := w.Commit(); err != nil {
+			t.Fatal("unexpected error", err)
+		}
+
+		if exp, got := int64(2), cw.NumWritten(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := int64(1), cw.NumInvalid(); exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+
+		r, err := feedx.NewReader(t.Context(), obj, nil)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		defer r.Close()
+
+		if msgs := drainReader(t, r); len(msgs) != 2 {
+			t.Errorf("expected 2 records, got %v", msgs)
+		}
+	})
+
+	t.Run("producer status", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		pcr := feedx.NewProducerForRemote(obj)
+		defer pcr.Close()
+
+		status, err := pcr.Produce(t.Context(), 101, &feedx.WriterOptions{Validate: validate, OnInvalid: feedx.SkipInvalid}, func(w *feedx.Writer) error {
+			return errors.Join(encodeAll(w)...)
+		})
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if exp, got := int64(2), status.NumItems; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+		if exp, got := int64(1), status.NumInvalidItems; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+
+	t.Run("producer aborts", func(t *testing.T) {
+		obj := bfs.NewInMemObject("path/to/file.json")
+		defer obj.Close()
+
+		pcr := feedx.NewProducerForRemote(obj)
+		defer pcr.Close()
+
+		attempts := 0
+		if _, err := pcr.Produce(t.Context(), 101, &feedx.WriterOptions{
+			Validate:  validate,
+			OnInvalid: feedx.AbortInvalid,
+			Retry:     &feedx.RetryPolicy{MaxAttempts: 3},
+		}, func(w *feedx.Writer) error {
+			attempts++
+			encodeAll(w) // ignore errors
+			return nil
+		}); !errors.Is(err, feedx.ErrInvalidRecord) {
+			t.Fatalf("expected %v, got %v", feedx.ErrInvalidRecord, err)
+		}
+		if exp, got := 1, attempts; exp != got {
+			t.Errorf("expected %v, got %v", exp, got)
+		}
+	})
+}
+
+func TestValidateProtoRequired(t *testing.T) {
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("validate_test.proto"),
+		Package: proto.String("feedx.internal.testdata"),
+		Syntax:  proto.String("proto2"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Strict"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:   proto.String("id"),
+				Number: proto.Int32(1),
+				Label:  descriptorpb.FieldDescriptorProto_LABEL_REQUIRED.Enum(),
+				Type:   descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum(),
+			}},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+
+	desc := fd.Messages().ByName("Strict")
+	msg := dynamicpb.NewMessage(desc)
+	if err := feedx.ValidateProtoRequired(msg); err == nil {
+		t.Error("expected error")
+	}
+
+	msg.Set(desc.Fields().ByName("id"), desc.Fields().ByName("id").Default())
+	if err := feedx.ValidateProtoRequired(msg); err != nil {
+		t.Error("unexpected error", err)
+	}
+	if err := feedx.ValidateProtoRequired(map[string]interface{}{}); err != nil {
+		t.Error("unexpected error", err)
+	}
+}
+
+func TestValidateJSONSchema(t *testing.T) {
+	validate := feedx.ValidateJSONSchema(mustJSONSchema(t, `{
+		"type": "object",
+		"required": ["name"],
+		"properties": {
+			"name": {"type": "string"},
+			"height": {"type": "integer"},
+			"tags": {"type": "array", "items": {"type": "string"}}
+		}
+	}`))
+
+	for _, rec := range []interface{}{
+		seed(),
+		map[string]interface{}{"name": "Joe", "height": 180, "tags": []string{"a"}},
+		map[string]interface{}{"name": "Joe", "height": 180.0},
+	} {
+		if err := validate(rec); err != nil {
+			t.Errorf("expected %v to be valid, got %v", rec, err)
+		}
+	}
+
+	for _, rec := range []interface{}{
+		&testdata.MockMessage{Height: 180},
+		map[string]interface{}{"name": 1},
+		map[string]interface{}{"name": "Joe", "height": 1.5},
+		map[string]interface{}{"name": "Joe", "tags": []interface{}{"a", 2}},
+		[]string{"Joe"},
+	} {
+		if err := validate(rec); err == nil {
+			t.Errorf("expected %v to be invalid", rec)
+		}
+	}
+
+	// 64-bit integers are validated as numbers
+	validate64 := feedx.ValidateJSONSchema(mustJSONSchema(t, `{
+		"type": "object",
+		"properties": {
+			"positive_int_value": {"type": "integer"},
+			"negative_int_value": {"type": "integer"}
+		}
+	}`))
+	if err := validate64(&descriptorpb.UninterpretedOption{
+		PositiveIntValue: proto.Uint64(math.MaxUint64),
+		NegativeIntValue: proto.Int64(-1 << 62),
+	}); err != nil {
+		t.Error("unexpected error", err)
+	}
+
+	if err := feedx.ValidateJSONSchema(feedx.ProtobufSchema(seed().ProtoReflect().Descriptor()))(seed()); err == nil {
+		t.Error("expected error")
+	}
+}
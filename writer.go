@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"strconv"
 
 	"github.com/bsm/bfs"
@@ -23,6 +25,60 @@ type WriterOptions struct {
 	// Provides an optional version which is stored with the remote metadata.
 	// Default: 0
 	Version int64
+
+	// Retry specifies the policy for retrying failed remote operations of
+	// producers, such as version checks, manifest loads and commits.
+	// Default: nil (no retries)
+	Retry *RetryPolicy
+
+	// Metrics records producer sync metrics.
+	// Default: nil (disabled)
+	Metrics Metrics
+
+	// Logger logs sync attempts and remote operations.
+	// Default: nil (disabled)
+	Logger *slog.Logger
+
+	// Feed identifies the feed in metrics, logs and traces. It should be set
+	// for producers created from buckets, which are otherwise all labeled
+	// after their manifest.
+	// Default: the URL the producer was created with, or the remote name.
+	Feed string
+
+	// Schema describes the records. It is published to SchemaRemote and, for
+	// incremental and partitioned feeds, in the manifest. Producers reject
+	// schemas which are incompatible with the previously published schema
+	// with an ErrIncompatibleSchema error.
+	// Default: nil (no schema)
+	Schema Schema
+
+	// SchemaRemote is the object the Schema is written to on Commit, next to
+	// the feed, see LoadSchema. Required by Producer if a Schema is set,
+	// incremental and partitioned producers store the schema in the manifest.
+	// Default: nil (not written)
+	SchemaRemote *bfs.Object
+
+	// Validate is called with every record before it is encoded. Records
+	// which fail validation are handled according to OnInvalid.
+	// Default: nil (no validation)
+	Validate ValidateFunc
+
+	// OnInvalid specifies how records which fail validation are handled.
+	// Default: RejectInvalid
+	OnInvalid InvalidAction
+
+	// SortBy configures the writer to sort records by key. Encoded records
+	// are buffered and written in key order on Commit, the name of the sort
+	// key is published with the remote metadata. Raw writes bypass sorting
+	// and must not be mixed with Encode.
+	// Default: nil (records are written in the order they are encoded)
+	SortBy *SortOptions
+
+	// Index configures the writer to produce a block-structured feed and
+	// write an index of the blocks on Commit. Raw writes bypass indexing
+	// and must not be mixed with Encode.
+	// Default: nil (no index)
+	Index *IndexOptions
 }
 
 func (o *WriterOptions) norm(name string) {
@@ -34,6 +90,34 @@ func (o *WriterOptions) norm(name string) {
 	}
 }
 
+func (o *WriterOptions) retryPolicy() *RetryPolicy {
+	if o == nil {
+		return nil
+	}
+	return o.Retry
+}
+
+func (o *WriterOptions) metrics() Metrics {
+	if o == nil {
+		return nil
+	}
+	return o.Metrics
+}
+
+func (o *WriterOptions) logger() *slog.Logger {
+	if o == nil {
+		return discardLogger
+	}
+	return loggerOrDiscard(o.Logger)
+}
+
+func (o *WriterOptions) feed(name string) string {
+	if o == nil || o.Feed == "" {
+		return name
+	}
+	return o.Feed
+}
+
 // Writer encodes feeds to remote locations.
 type Writer struct {
 	ctx    context.Context
@@ -41,10 +125,20 @@ type Writer struct {
 	opt    WriterOptions
 	num    int64
 
+	numInvalid int64
+	abortErr   error
+
+	numBytes           int64 // uncompressed
+	numCompressedBytes int64
+
+	span Span
+
 	bw bfs.Writer
 	cw io.WriteCloser // compression writer
 	ww *bufio.Writer
 	fe FormatEncoder
+	se *sortingEncoder
+	ib *indexBuilder
 }
 
 // NewWriter inits a new feed writer.
@@ -78,12 +172,28 @@ func (w *Writer) WriteString(s string) (int, error) {
 	return w.ww.WriteString(s)
 }
 
-// Encode appends a value to the feed.
+// Encode appends a value to the feed. If a Validate function is
+// configured, invalid values are handled according to OnInvalid.
 func (w *Writer) Encode(v interface{}) error {
+	if err := w.checkIndex(); err != nil {
+		return err
+	}
+	if ok, err := w.accept(w.validate(v)); !ok {
+		return err
+	}
+
+	if w.opt.SortBy != nil {
+		return w.encodeSorted(v)
+	}
+
 	if err := w.ensureCreated(); err != nil {
 		return err
 	}
 
+	if err := w.beginIndexed(v); err != nil {
+		return err
+	}
+
 	if w.fe == nil {
 		fe, err := w.opt.Format.NewEncoder(w.ww)
 		if err != nil {
@@ -100,11 +210,85 @@ func (w *Writer) Encode(v interface{}) error {
 	return nil
 }
 
+func (w *Writer) encodeSorted(v interface{}) error {
+	se, err := w.sortingEncoder()
+	if err != nil {
+		return err
+	}
+	if err := se.Encode(v); err != nil {
+		return err
+	}
+
+	w.num++
+	return nil
+}
+
+// writeEncoded appends a value which has already been encoded by the
+// configured format.
+func (w *Writer) writeEncoded(v interface{}, data []byte) error {
+	if err := w.checkIndex(); err != nil {
+		return err
+	}
+	if w.opt.SortBy != nil {
+		se, err := w.sortingEncoder()
+		if err != nil {
+			return err
+		}
+		if err := se.Add(v, data); err != nil {
+			return err
+		}
+
+		w.num++
+		return nil
+	}
+
+	if err := w.ensureCreated(); err != nil {
+		return err
+	}
+	if err := w.beginIndexed(v); err != nil {
+		return err
+	}
+	if _, err := w.ww.Write(data); err != nil {
+		return err
+	}
+
+	w.num++
+	return nil
+}
+
+func (w *Writer) sortingEncoder() (*sortingEncoder, error) {
+	if w.se == nil {
+		se, err := newSortingEncoder(w.opt.Format, w.opt.SortBy)
+		if err != nil {
+			return nil, err
+		}
+		w.se = se
+	}
+	return w.se, nil
+}
+
 // NumWritten returns the number of written values.
 func (w *Writer) NumWritten() int64 {
 	return w.num
 }
 
+// NumInvalid returns the number of values which failed validation.
+func (w *Writer) NumInvalid() int64 {
+	return w.numInvalid
+}
+
+// NumBytes returns the number of uncompressed bytes written. Buffered
+// data is only accounted for once it's flushed, i.e. after Commit.
+func (w *Writer) NumBytes() int64 {
+	return w.numBytes
+}
+
+// NumCompressedBytes returns the number of compressed bytes written to
+// the remote.
+func (w *Writer) NumCompressedBytes() int64 {
+	return w.numCompressedBytes
+}
+
 // Discard closes the writer and discards the contents.
 func (w *Writer) Discard() error {
 	err := w.close()
@@ -113,21 +297,212 @@ func (w *Writer) Discard() error {
 			err = errors.Join(err, e)
 		}
 	}
+	w.endSpan(false, err)
 	return err
 }
 
-// Commit closes the writer and persists the contents.
+// Commit closes the writer and persists the contents. If the writer was
+// aborted due to an invalid value, the contents are discarded instead and
+// the validation error is returned.
 func (w *Writer) Commit() error {
+	if w.abortErr != nil {
+		if err := w.Discard(); err != nil {
+			return errors.Join(w.abortErr, err)
+		}
+		return w.abortErr
+	}
+
+	if w.se != nil {
+		if err := w.se.WriteTo(w); err != nil {
+			return errors.Join(err, w.Discard())
+		}
+	}
+
 	err := w.close()
+	if err == nil && w.bw != nil {
+		// the schema and the index are written first, a feed is never
+		// committed without them
+		if err = w.writeSidecars(); err != nil {
+			err = errors.Join(err, w.bw.Discard())
+			w.endSpan(false, err)
+			return err
+		}
+	}
 	if w.bw != nil {
 		if e := w.bw.Commit(); e != nil {
 			err = errors.Join(err, e)
 		}
 	}
+	w.endSpan(true, err)
+	if err == nil && w.bw != nil {
+		w.opt.logger().Debug("committed object",
+			"object", w.remote.Name(),
+			"version", w.opt.Version,
+			"items", w.num,
+			"bytes", w.numBytes,
+		)
+	}
 	return err
 }
 
+// writeSidecars writes the schema and the index of the feed.
+func (w *Writer) writeSidecars() error {
+	if w.opt.Schema != nil && w.opt.SchemaRemote != nil {
+		enc, err := encodeSchema(w.opt.Schema)
+		if err != nil {
+			return err
+		}
+		if err := writeSchema(w.ctx, w.opt.SchemaRemote, enc, &w.opt); err != nil {
+			return err
+		}
+	}
+	if w.ib != nil {
+		return w.writeIndex()
+	}
+	return nil
+}
+
+// checkIndex checks that the compression supports indexed feeds.
+func (w *Writer) checkIndex() error {
+	if w.opt.Index != nil && w.opt.Compression == FlateCompression {
+		return errIndexCompression
+	}
+	return nil
+}
+
+// beginIndexed records a value of an indexed feed.
+func (w *Writer) beginIndexed(v interface{}) error {
+	if w.opt.Index == nil {
+		return nil
+	}
+
+	var key string
+	if w.opt.Index.Key != nil {
+		key = w.opt.Index.Key(v)
+	}
+	return w.beginRecord(key)
+}
+
+// beginRecord starts a new block of an indexed feed, once the current block
+// is full, and records the record in the index.
+func (w *Writer) beginRecord(key string) error {
+	if w.ib == nil {
+		keyed := w.opt.Index.Key != nil || w.opt.SortBy != nil
+		w.ib = newIndexBuilder(w.opt.Index, w.opt.Version, keyed)
+	}
+
+	first := w.ib.index.NumRecords()
+	blocks := w.ib.index.Blocks
+	if n := len(blocks); n == 0 || w.numBytes+int64(w.ww.Buffered())-w.ib.blockStart >= w.opt.Index.blockSize() {
+		if n != 0 {
+			if err := w.ww.Flush(); err != nil {
+				return err
+			}
+			if err := w.cw.Close(); err != nil {
+				return err
+			}
+
+			cw, err := w.opt.Compression.NewWriter(countingWriter{w: w.bw, n: &w.numCompressedBytes})
+			if err != nil {
+				return err
+			}
+			w.cw = cw
+			w.ww.Reset(countingWriter{w: w.cw, n: &w.numBytes})
+		}
+
+		blocks = append(blocks, IndexBlock{
+			Offset:      w.numCompressedBytes,
+			FirstRecord: first,
+			FirstKey:    key,
+		})
+		w.ib.blockStart = w.numBytes
+	}
+
+	if w.ib.index.Sorted && key < w.ib.lastKey {
+		w.ib.index.Sorted = false
+	}
+	w.ib.lastKey = key
+
+	blocks[len(blocks)-1].NumRecords++
+	w.ib.index.Blocks = blocks
+	return nil
+}
+
+// writeIndex writes the index of a committed, indexed feed.
+func (w *Writer) writeIndex() error {
+	blocks := w.ib.index.Blocks
+	for i := range blocks {
+		end := w.numCompressedBytes
+		if i+1 < len(blocks) {
+			end = blocks[i+1].Offset
+		}
+		blocks[i].Size = end - blocks[i].Offset
+	}
+
+	iw := NewWriter(w.ctx, w.opt.Index.Remote, &WriterOptions{
+		Format:  JSONFormat,
+		Version: w.opt.Version,
+		Logger:  w.opt.Logger,
+	})
+	defer iw.Discard()
+
+	if err := iw.Encode(&w.ib.index); err != nil {
+		return err
+	}
+	return iw.Commit()
+}
+
+// accept handles the result of a validation and reports whether the value
+// should be written.
+func (w *Writer) accept(invalid error) (bool, error) {
+	if w.abortErr != nil {
+		return false, w.abortErr
+	}
+	if invalid == nil {
+		return true, nil
+	}
+
+	w.numInvalid++
+	switch w.opt.OnInvalid {
+	case SkipInvalid:
+		return false, nil
+	case AbortInvalid:
+		w.abortErr = invalid
+	}
+	return false, invalid
+}
+
+func (w *Writer) validate(v interface{}) error {
+	if w.opt.Validate == nil {
+		return nil
+	}
+	if err := w.opt.Validate(v); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRecord, err)
+	}
+	return nil
+}
+
+func (w *Writer) endSpan(committed bool, err error) {
+	if w.span == nil {
+		return
+	}
+
+	w.span.SetAttributes(
+		slog.Bool("feedx.committed", committed),
+		slog.Int64("feedx.items", w.num),
+		slog.Int64("feedx.bytes", w.numBytes),
+	)
+	endSpan(w.span, err)
+	w.span = nil
+}
+
 func (w *Writer) close() (err error) {
+	if w.se != nil {
+		if e := w.se.Close(); e != nil {
+			err = errors.Join(err, e)
+		}
+		w.se = nil
+	}
 	if w.fe != nil {
 		if e := w.fe.Close(); e != nil {
 			err = errors.Join(err, e)
@@ -147,10 +522,17 @@ func (w *Writer) close() (err error) {
 }
 
 func (w *Writer) ensureCreated() error {
+	if w.span == nil {
+		w.ctx, w.span = startSpan(w.ctx, "feedx.write", slog.String("feedx.object", w.remote.Name()))
+	}
+
 	if w.bw == nil {
-		bw, err := w.remote.Create(w.ctx, &bfs.WriteOptions{
-			Metadata: bfs.Metadata{metaVersion: strconv.FormatInt(w.opt.Version, 10)},
-		})
+		meta := bfs.Metadata{metaVersion: strconv.FormatInt(w.opt.Version, 10)}
+		if w.opt.SortBy != nil {
+			meta[metaSortedBy] = w.opt.SortBy.Name
+		}
+
+		bw, err := w.remote.Create(w.ctx, &bfs.WriteOptions{Metadata: meta})
 		if err != nil {
 			return err
 		}
@@ -158,7 +540,7 @@ func (w *Writer) ensureCreated() error {
 	}
 
 	if w.cw == nil {
-		cw, err := w.opt.Compression.NewWriter(w.bw)
+		cw, err := w.opt.Compression.NewWriter(countingWriter{w: w.bw, n: &w.numCompressedBytes})
 		if err != nil {
 			return err
 		}
@@ -166,8 +548,19 @@ func (w *Writer) ensureCreated() error {
 	}
 
 	if w.ww == nil {
-		w.ww = bufio.NewWriter(w.cw)
+		w.ww = bufio.NewWriter(countingWriter{w: w.cw, n: &w.numBytes})
 	}
 
 	return nil
 }
+
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (c countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.n += int64(n)
+	return n, err
+}